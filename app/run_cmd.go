@@ -29,26 +29,27 @@ func (app *app) runCmd() *cobra.Command {
 	return runCmd
 }
 
-// run creates and initiates the communication channels, the game, the hub and the http server
+// run creates and initiates the communication channels, the game rooms, the hub and the http server
 func run(ctx context.Context) error {
-	// Create the control channel on which the hub will push client control notyfications to the game
+	// Create the control channel on which the hub will push client control notyfications to the game rooms
 	controlCh := make(chan *model.ControlNotify)
-	// Create the game
-	game := game.NewGameController(ctx, time.Millisecond*33, controlCh)
+	// Create the room manager, which runs the games
+	rooms := game.NewRoomManager(ctx, time.Second/game.FrameRate, controlCh)
 	// Create the hub
 	hub := core.NewWsHub(ctx, controlCh)
 	// Create the server
 	server := server.NewServer(ctx)
 
 	// Pass in callback functions the these objects
-	hub.SetRequestFn(game.Request)               // the hub can call the game with arbitary client requests (login)
-	hub.SetLogoutFn(game.Logout)                 // the hub can call the game with when a conn is dropped, to remove the player
-	game.SetBroadcastFn(hub.BroadcastGameUpdate) // the game can call the hub to broadcast state update
-	game.SetConnStatusFn(hub.ChangeConnStatus)   // the game can call the hub to change a connection's status (ingame)
-	server.SetConnectFn(hub.Connect)             // the server can call the hub to add a new WebSocket connection (new client)
-
-	// Start the game and the hub
-	game.Start()
+	hub.SetRequestFn(rooms.Request)             // the hub can call the rooms with arbitary client requests (login, rooms)
+	hub.SetLogoutFn(rooms.Logout)               // the hub can call the rooms with when a conn is dropped, to remove the player
+	hub.SetAckFn(rooms.Ack)                     // the hub can call the rooms with the last world update a client received
+	rooms.SetSendFn(hub.Send)                   // the rooms can call the hub to send a message to a single client
+	rooms.SetConnStatusFn(hub.ChangeConnStatus) // the rooms can call the hub to change a connection's status (ingame)
+	server.SetConnectFn(hub.Connect)            // the server can call the hub to add a new WebSocket connection (new client)
+
+	// Start the rooms and the hub
+	rooms.Start()
 	hub.Start()
 	// Start the server, return any error
 	return server.Start()
@@ -25,6 +25,7 @@ type WsHub struct {
 
 	requestFn func(req *model.ClientRequest)
 	logoutFn  func(clientId string)
+	ackFn     func(clientId string, frame int64)
 }
 
 // NewWsHub creates a new WsHub in the given context and initializes it
@@ -35,6 +36,8 @@ func NewWsHub(ctx context.Context, controlCh chan *model.ControlNotify) *WsHub {
 		errorCh:     make(chan error),
 		controlCh:   controlCh,
 		requestFn:   func(req *model.ClientRequest) {},
+		logoutFn:    func(clientId string) {},
+		ackFn:       func(clientId string, frame int64) {},
 	}
 }
 
@@ -52,6 +55,13 @@ func (hub *WsHub) SetLogoutFn(f func(clientId string)) {
 	hub.logoutFn = f
 }
 
+func (hub *WsHub) SetAckFn(f func(clientId string, frame int64)) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.ackFn = f
+}
+
 // Start sets up and starts the WebSocket Hub
 func (hub *WsHub) Start() {
 	hub.initOnce.Do(func() {
@@ -117,6 +127,20 @@ func (hub *WsHub) ChangeConnStatus(clientId string, status model.ConnStatus) {
 	}
 }
 
+// connStatus returns the status of the connection with the given Client ID
+func (hub *WsHub) connStatus(clientId string) model.ConnStatus {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	for _, conn := range hub.conns {
+		if conn.clientId == clientId {
+			conn.mu.Lock()
+			defer conn.mu.Unlock()
+			return conn.status
+		}
+	}
+	return model.Status_Unknown
+}
+
 func (hub *WsHub) notify(clientId string, msg *model.ServerMsg) {
 	log.Debugf("Notifying client %s", clientId)
 	for _, conn := range hub.conns {
@@ -181,6 +205,11 @@ func (hub *WsHub) Broadcast(msg *model.ServerMsg, statuses ...model.ConnStatus)
 	}
 }
 
+// Send sends the message to the client with the given ID
+func (hub *WsHub) Send(clientId string, msg *model.ServerMsg) {
+	hub.notify(clientId, msg)
+}
+
 func (hub *WsHub) BroadcastGameUpdate(msg *model.ServerMsg) {
 	hub.Broadcast(msg, model.Status_InGame)
 }
@@ -195,7 +224,12 @@ func (hub *WsHub) handleClientMsg(msg *model.ClientMsg) {
 			hub.onChat(msg.Notify.Chat)
 		// in case of Control
 		case model.Notify_Control:
-			hub.onControl(msg.Notify.Control)
+			hub.onControl(msg.ClientId, msg.Notify.Control)
+		// in case of Acknowledgement
+		case model.Notify_Ack:
+			if msg.Notify.Ack != nil {
+				hub.ackFn(msg.ClientId, msg.Notify.Ack.Frame)
+			}
 		}
 
 	// in case of Request
@@ -213,6 +247,20 @@ func (hub *WsHub) onChat(chat *model.ChatNotify) {
 	// hub.Broadcast(msg, model.Status_Connected, model.Status_Authenticated, model.Status_InGame)
 }
 
-func (hub *WsHub) onControl(control *model.ControlNotify) {
-
+// onControl tags the control notification with the sender's Client ID and pushes it to the game,
+// controls from connections which are not in the game are dropped
+func (hub *WsHub) onControl(clientId string, control *model.ControlNotify) {
+	if control == nil {
+		log.Warnf("Empty control notification from client %s", clientId)
+		return
+	}
+	if !hub.connStatus(clientId).StatusIn([]model.ConnStatus{model.Status_InGame}) {
+		log.Debugf("Dropping control notification from client %s as it is not in game", clientId)
+		return
+	}
+	control.ClientId = clientId
+	select {
+	case hub.controlCh <- control:
+	case <-hub.ctx.Done():
+	}
 }
@@ -29,21 +29,37 @@ class App {
     // to request login access to the game
     this.login.requestFn = this.ws.request;
 
+    // The InputManager can use the WebSocketManager's notify method
+    // to send the pressed and released keys to the server
+    this.input.notifyFn = this.ws.notify;
+
     // The LoginManager will create the GameWorld and the Display upon successful login
     // using the World Data retrieved from the server
     this.login.onSuccessFn = world_data => {
       this.world = new GameWorld(world_data);
       this.display = new Display(this.world, this.assets.getAll());
       this.engine.initEngine(this.display.render);
+      this.input.initInput();
     };
 
     // On incoming server updates the GameWorld will be updated accordingly
     // and and drawn to the Display
     this.ws.onUpdateFn = update => {
-      this.world.updateWorld(update);
+      if (!this.world.updateWorld(update)) {
+        this.ws.request("resync", "", () => {});
+        return
+      };
+      this.ws.notify(AckMessage(update.frame));
+      this.input.frame = update.frame;
       this.display.drawWorld(this.world);
     };
 
+    // On map change the GameWorld gets the new map, and the Display is resized to it
+    this.ws.onMapFn = map => {
+      this.world.changeMap(map);
+      this.display.setWorld(this.world);
+    };
+
     this.run = () => {
       this.ws.initWs();
       this.login.initLogin();
@@ -1,23 +1,35 @@
 "use strict";
 
+// ParticleColors are the colors of the particles by their object type
+const ParticleColors = {
+  "fur":    "#d8c8b0",
+  "blood":  "#b00000",
+  "splash": "#a0c8ff",
+  "dust":   "#c0a080",
+};
+
+// ParticleSize is the size of a particle in pixels
+const ParticleSize = 2;
+
+// PickupColors are the colors of the collectible items by their object type
+const PickupColors = {
+  "jetpack": "#ff8000",
+  "boots":   "#00c000",
+  "shield":  "#4080ff",
+};
+
 class Display {
   constructor(world, assets) {
     this.updated = false;
 
     this.assets  = assets || {};
 
-    this.width         = world.widthPx();
-    this.height        = world.heightPx();
-
-    this.widthToHeight = this.width / this.height;
-
     this.gamePage      = document.getElementById("GamePage");
     this.gameCanvas    = document.getElementById("GameCanvas");
     this.ctx           = this.gameCanvas.getContext("2d");
     this.buffer        = document.createElement("canvas").getContext("2d");
-    this.buffer.canvas.height = this.height;
-    this.buffer.canvas.width  = this.width;
 
+    this.setWorld      = this.setWorld.bind(this);
     this.resize        = this.resize.bind(this);
     this.render        = this.render.bind(this);
     this.drawBox       = this.drawBox.bind(this);
@@ -28,6 +40,18 @@ class Display {
 
     window.addEventListener("resize", this.resize);
     window.addEventListener("orientationchange", this.resize);
+    this.setWorld(world);
+  };
+
+  // setWorld sizes the buffer to the world's map
+  setWorld(world) {
+    this.width         = world.widthPx();
+    this.height        = world.heightPx();
+
+    this.widthToHeight = this.width / this.height;
+
+    this.buffer.canvas.height = this.height;
+    this.buffer.canvas.width  = this.width;
     this.resize();
   };
 
@@ -95,6 +119,15 @@ class Display {
     };
 
     world.world_objects.forEach(obj => {
+      if (ParticleColors.hasOwnProperty(obj.obj_type)) {
+        this.drawBox(obj.x, obj.y, ParticleSize, ParticleSize, ParticleColors[obj.obj_type]);
+        return
+      };
+      if (PickupColors.hasOwnProperty(obj.obj_type)) {
+        let size = world.world_rules.block_size;
+        this.drawBox(obj.x, obj.y, size, size, PickupColors[obj.obj_type]);
+        return
+      };
       this.drawAnim(obj.x, obj.y, obj.obj_type, obj.anim);
     });
 
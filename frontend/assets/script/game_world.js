@@ -9,9 +9,33 @@ class GameWorld {
     this.players       = opts.players       || [];
     this.world_objects = opts.world_objects || [];
 
-    this.updateWorld = opts => {
-      this.players       = opts.players       || this.players;
-      this.world_objects = opts.world_objects || this.world_objects;
+    // states are the received world states by frame, deltas are applied on these
+    this.states = {};
+
+    // updateWorld applies a keyframe or a delta world update, returns false
+    // if the delta's base frame is not known, and a resync is needed
+    this.updateWorld = update => {
+      let base = update.keyframe ? { players: [], world_objects: [] } : this.states[update.base_frame];
+      if (!base) {
+        return false
+      };
+      let players = mergeBy(base.players, update.players, update.removed_players, p => p.name);
+      let objects = mergeBy(base.world_objects, update.world_objects, update.removed_objects, o => o.id);
+      this.states[update.frame] = { players: players, world_objects: objects };
+      Object.keys(this.states).forEach(frame => {
+        if (frame < update.frame - 90) {
+          delete this.states[frame];
+        };
+      });
+      this.players       = players;
+      this.world_objects = objects;
+      return true
+    };
+
+    // changeMap replaces the map and the block size, when the room moves on to the next level
+    this.changeMap = map => {
+      this.world_map = new WorldMap(map.world_map || {});
+      this.world_rules.block_size = map.block_size || this.world_rules.block_size;
     };
 
     this.width    = () => this.world_map.width();
@@ -45,3 +69,13 @@ class WorldMap {
     this.get    = (x, y) => this.rows[y][x];
   };
 };
+
+// mergeBy returns a copy of the list with the changed elements replaced or added,
+// and the removed ones deleted, the elements are identified by the key function
+function mergeBy(list, changed, removed, key) {
+  let merged = {};
+  (list || []).forEach(elem => merged[key(elem)] = elem);
+  (changed || []).forEach(elem => merged[key(elem)] = elem);
+  (removed || []).forEach(id => delete merged[id]);
+  return Object.values(merged);
+};
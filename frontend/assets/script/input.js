@@ -1,7 +1,47 @@
 "use strict";
 
+// KeyMap maps the keyboard keys to the control keys of the game
+const KeyMap = {
+  "ArrowLeft":  "left",
+  "ArrowRight": "right",
+  "ArrowUp":    "jump",
+  "a":          "left",
+  "d":          "right",
+  "w":          "jump",
+  " ":          "jump",
+};
+
+// InputManager listens to the keyboard and notifies the server
+// every time a control key is pressed or released
 class InputManager {
   constructor(){
+    this.held = {};
+    this.seq  = 0; // sequence number of the last control notification
+    this.frame = 0; // the last world update received from the server
+
+    this.notifyFn = () => {};
+
+    this.initInput = this.initInput.bind(this);
+    this.onKey     = this.onKey.bind(this);
+  };
+
+  initInput() {
+    window.addEventListener("keydown", this.onKey);
+    window.addEventListener("keyup", this.onKey);
+  };
 
+  onKey(event) {
+    if (!KeyMap.hasOwnProperty(event.key)) {
+      return
+    };
+    event.preventDefault();
+    let controlKey = KeyMap[event.key];
+    let pressed = event.type == "keydown";
+    if (this.held[controlKey] == pressed) { // ignore the repeated keydown events
+      return
+    };
+    this.held[controlKey] = pressed;
+    this.seq++;
+    this.notifyFn(ControlMessage(event.type, controlKey, this.seq, this.frame));
   };
-};
\ No newline at end of file
+};
@@ -5,6 +5,7 @@ const colorDdict = {
   "2" : "blue",
   "3" : "aqua",
   "4" : "red",
+  "5" : "gold",
 };
 
 function numToColor(num) {
@@ -10,6 +10,9 @@ class WebSocketManager {
     // onUpdateFn needs to be overriden with the GameWorld's onUpdate method
     this.onUpdateFn = update => { console.log(update); };
 
+    // onMapFn needs to be overriden to change the map of the GameWorld
+    this.onMapFn = map => { console.log(map); };
+
     // ready returns true if the WebSocket is ready for read and write
     this.ready          = () => this.ws && this.ws.readyState == WebSocket.OPEN;
 
@@ -81,6 +84,10 @@ class WebSocketManager {
       this.onUpdateFn(msg.world_update);
       return
     };
+    if (msg.msg_type == "map") {
+      this.onMapFn(msg.map);
+      return
+    };
     if (msg.msg_type == "response") {
       this.handleResponse(msg.response);
       return
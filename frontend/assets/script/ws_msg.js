@@ -10,9 +10,19 @@ class ChatNotify {
 
 // ControlNotify is sent to the server when the user initiates a control command
 class ControlNotify {
-  constructor(controlType, controlKey) {
+  constructor(controlType, controlKey, seq, clientTime, frame) {
     this.control_type = controlType;
-    this.contorl_key = controlKey;
+    this.control_key = controlKey;
+    this.seq = seq;
+    this.client_time = clientTime;
+    this.frame = frame;
+  };
+};
+
+// AckNotify is sent to the server when a world update is received
+class AckNotify {
+  constructor(frame) {
+    this.frame = frame;
   };
 };
 
@@ -27,6 +37,9 @@ class ClientNotify {
     if (opts.hasOwnProperty("control")) {
       this.control = opts.control;
     };
+    if (opts.hasOwnProperty("ack")) {
+      this.ack = opts.ack;
+    };
   };
 };
 
@@ -63,10 +76,19 @@ function ChatMessage(channel, message) {
 };
 
 // ControlMessage creates a control type ClientMsg
-function ControlMessage(controlType, controlKey) {
+function ControlMessage(controlType, controlKey, seq, frame) {
   return new ClientMsg("notify", {
     notify: new ClientNotify("control", {
-      contrl: new ControlNotify(controlType, controlKey),
+      control: new ControlNotify(controlType, controlKey, seq, Date.now(), frame),
+    }),
+  });
+};
+
+// AckMessage creates an ack type ClientMsg
+function AckMessage(frame) {
+  return new ClientMsg("notify", {
+    notify: new ClientNotify("ack", {
+      ack: new AckNotify(frame),
     }),
   });
 };
@@ -12,29 +12,42 @@ import (
 	"github.com/donbattery/bnj/utils"
 )
 
+// FrameRate is the number of frames the game world is updated in every second
+const FrameRate = 30
+
 type GameController struct {
 	mu           sync.RWMutex
 	ctx          context.Context
 	initOnce     sync.Once
+	name         string
 	world        *gameWorld
-	frame        int64
 	step         time.Duration
 	controlCh    chan *model.ControlNotify
-	broadcastFn  func(msg *model.ServerMsg)
+	viewers      map[string]*viewer
+	history      map[int64]*snapshot
+	sendFn       func(clientId string, msg *model.ServerMsg)
 	connStatusFn func(clientId string, status model.ConnStatus)
+	replayFn     func(replay model.Replay)
 }
 
-func NewGameController(ctx context.Context, step time.Duration, controlCh chan *model.ControlNotify) *GameController {
-	cfg := utils.Conf(ctx)
+// NewGameController creates a game (a room) with the given name, rules and map.
+// The game is updated once every step, and the players' controls are received on the control channel
+func NewGameController(ctx context.Context, name string, rules model.WorldRules, worldMap model.WorldMap, step time.Duration, controlCh chan *model.ControlNotify) *GameController {
+	// Every game gets a new seed, log it so the game can be reproduced
+	seed := time.Now().UnixNano()
+	log.Infof("Creating game world %s with seed %d", name, seed)
 
 	return &GameController{
 		ctx:          ctx,
+		name:         name,
 		step:         step,
-		frame:        0,
 		controlCh:    controlCh,
-		world:        newGameWorld(cfg.WorldRules, model.DefaultWorldMap()),
-		broadcastFn:  func(msg *model.ServerMsg) {},
+		world:        newGameWorld(rules, worldMap, seed),
+		viewers:      make(map[string]*viewer),
+		history:      make(map[int64]*snapshot),
+		sendFn:       func(clientId string, msg *model.ServerMsg) {},
 		connStatusFn: func(clientId string, status model.ConnStatus) {},
+		replayFn:     func(replay model.Replay) {},
 	}
 }
 
@@ -42,14 +55,24 @@ func NewGameController(ctx context.Context, step time.Duration, controlCh chan *
 /// Public Methods //
 ////////////////////
 
-func (gc *GameController) SetBroadcastFn(f func(msg *model.ServerMsg)) {
-	gc.broadcastFn = f
+func (gc *GameController) SetSendFn(f func(clientId string, msg *model.ServerMsg)) {
+	gc.sendFn = f
 }
 
 func (gc *GameController) SetConnStatusFn(f func(clientId string, status model.ConnStatus)) {
 	gc.connStatusFn = f
 }
 
+// SetReplayFn sets the function receiving the replay of every finished round
+func (gc *GameController) SetReplayFn(f func(replay model.Replay)) {
+	gc.replayFn = f
+}
+
+// SetPlaylist sets the levels the game rotates through between the rounds
+func (gc *GameController) SetPlaylist(levels []model.Level) {
+	gc.world.setPlaylist(levels)
+}
+
 func (gc *GameController) Start() {
 	gc.initOnce.Do(func() {
 		go gc.run()
@@ -59,7 +82,9 @@ func (gc *GameController) Start() {
 func (gc *GameController) Request(req *model.ClientRequest) {
 	switch req.RequestType {
 	case "login":
-		gc.handleLogin(req)
+		gc.Login(req)
+	case "resync":
+		gc.handleResync(req)
 	default:
 		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Unknown request type %s", req.RequestType))
 	}
@@ -67,6 +92,127 @@ func (gc *GameController) Request(req *model.ClientRequest) {
 
 func (gc *GameController) Logout(clientId string) {
 	gc.world.removePlayer(clientId)
+
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	delete(gc.viewers, clientId)
+}
+
+// Login adds the requesting client to the game as a player, returns true on success
+func (gc *GameController) Login(req *model.ClientRequest) bool {
+	// Validate LoginRequest
+	var loginRequest model.LoginRequest
+	if err := json.Unmarshal([]byte(req.RequestBody), &loginRequest); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid LoginRequest JSON %s", err.Error()))
+		return false
+	}
+	if err := loginRequest.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid LoginRequest %s", err.Error()))
+		return false
+	}
+
+	// Check if the world has room for the player, and the name is not taken
+	if status, err := gc.world.admit(loginRequest.Name, loginRequest.Team); err != nil {
+		req.Response(status, err.Error())
+		return false
+	}
+
+	// Add the new player, its total score is kept from the previous games
+	player := newPlayer(req.ClientId, loginRequest.Name, loginRequest.Color, loginRequest.Skin)
+	player.team = loginRequest.Team
+	if stats, err := loadStats(utils.DB(gc.ctx), loginRequest.Name); err != nil {
+		log.Errorf("Failed to load the stats of %s %s", loginRequest.Name, err.Error())
+	} else {
+		player.totalScore = stats.TotalScore
+	}
+	gc.world.addPlayer(player)
+
+	// Change the associated wsConn's status to InGame
+	gc.connStatusFn(req.ClientId, model.Status_InGame)
+
+	// Start sending world updates to the player, if it was a spectator it is not anymore
+	gc.mu.Lock()
+	gc.viewers[req.ClientId] = &viewer{}
+	gc.mu.Unlock()
+
+	// Send the accepted status and the world dump to the player
+	req.Response(model.ResponseStatusAccepted, gc.world.dump())
+	return true
+}
+
+// Spectate adds the requesting client to the game as a spectator, who receives the world updates
+// without taking a player slot, a player of the game stops playing and becomes a spectator. Returns true on success
+func (gc *GameController) Spectate(req *model.ClientRequest) bool {
+	gc.mu.Lock()
+	if v, ok := gc.viewers[req.ClientId]; ok && v.spectator {
+		gc.mu.Unlock()
+		req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Already watching %s", gc.name))
+		return false
+	}
+	gc.viewers[req.ClientId] = &viewer{spectator: true}
+	gc.mu.Unlock()
+
+	// If the client was playing its player leaves the world
+	gc.world.removePlayer(req.ClientId)
+
+	// Change the associated wsConn's status to Authenticated
+	gc.connStatusFn(req.ClientId, model.Status_Authenticated)
+
+	// Send the accepted status and the world dump to the spectator
+	req.Response(model.ResponseStatusAccepted, gc.world.dump())
+	return true
+}
+
+// Spectating checks if the client is a spectator of the game
+func (gc *GameController) Spectating(clientId string) bool {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
+
+	v, ok := gc.viewers[clientId]
+	return ok && v.spectator
+}
+
+// Info returns the public information of the game
+func (gc *GameController) Info() model.RoomInfo {
+	gc.mu.RLock()
+	spectators := 0
+	for _, v := range gc.viewers {
+		if v.spectator {
+			spectators++
+		}
+	}
+	gc.mu.RUnlock()
+
+	gc.world.mu.RLock()
+	defer gc.world.mu.RUnlock()
+
+	return model.RoomInfo{
+		Name:       gc.name,
+		Players:    len(gc.world.players),
+		Humans:     gc.world.humans(),
+		Spectators: spectators,
+		MaxPlayer:  gc.world.rules.MaxPlayer,
+		Round:      gc.world.round.number,
+		Phase:      gc.world.round.phase,
+	}
+}
+
+// Empty checks if there is no client in the game
+func (gc *GameController) Empty() bool {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
+
+	return len(gc.viewers) == 0
+}
+
+// Ack records the last world update frame received by the client
+func (gc *GameController) Ack(clientId string, frame int64) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	if v, ok := gc.viewers[clientId]; ok {
+		v.ack(frame)
+	}
 }
 
 ///////////////////////
@@ -80,70 +226,72 @@ func (gc *GameController) run() {
 	for {
 		select { // We need to use double select to avoid the control ch to block the updates
 		case <-tick.C:
-			gc.frame++
 			gc.update()
-			go gc.broadcastFn(model.NewServerMsg(
-				model.ServerMsg_Update,
-				&model.WorldUpdate{
-					Players:      gc.world.playerDump(),
-					WorldObjects: gc.world.objectDump(),
-				}, nil, nil))
 		default: // With an empty default we proceed to the next select without stucking here
 		}
 
 		select {
 		case <-gc.ctx.Done():
-			log.Warnf("Game Loop's context is done, returning...")
+			log.Warnf("Game Loop of %s's context is done, returning...", gc.name)
 			return
 
 		case ctl := <-gc.controlCh:
-			log.Infof("Incoming control notify %s %s", ctl.ControlKey, ctl.ControlType)
+			log.Debugf("Incoming control notify from client %s %s %s", ctl.ClientId, ctl.ControlKey, ctl.ControlType)
+			gc.world.control(ctl)
 
 		case <-tick.C: // once again we check for the ticker
-			gc.frame++
 			gc.update()
-			go gc.broadcastFn(model.NewServerMsg(
-				model.ServerMsg_Update,
-				&model.WorldUpdate{
-					Players:      gc.world.playerDump(),
-					WorldObjects: gc.world.objectDump(),
-				}, nil, nil))
 		}
 	}
 }
 
+// update advances the game world by exactly one frame, regardless of the time passed since the last tick,
+// then broadcasts the collected messages and sends the state of the world to every viewer
 func (gc *GameController) update() {
-	time.Sleep(time.Millisecond * 10)
-}
+	gc.world.update()
+	msgs := gc.world.flush()
+	snap := gc.world.snapshot()
 
-func (gc *GameController) handleLogin(req *model.ClientRequest) {
-	// Validate LoginRequest
-	var loginRequest model.LoginRequest
-	if err := json.Unmarshal([]byte(req.RequestBody), &loginRequest); err != nil {
-		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid LoginRequest JSON %s", err.Error()))
-		return
+	for _, replay := range gc.world.flushReplays() {
+		replay.Room = gc.name
+		go gc.replayFn(replay)
 	}
 
-	if len(gc.world.players) >= gc.world.rules.MaxPlayer {
-		req.Response(model.ResponseStatusNotAccaptable, "Server is full")
-		return
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+
+	for _, msg := range msgs {
+		gc.broadcast(msg)
 	}
 
-	// Check if a player with the sname name is already connected to the game
-	for _, player := range gc.world.players {
-		if player.name == loginRequest.Name {
-			resp := fmt.Sprintf("Someone is already connected with the name %s", loginRequest.Name)
-			req.Response(model.ResponseStatusUnauthorized, resp)
-			return
-		}
+	// Keep the snapshots the viewers may acknowledge, drop the older ones
+	gc.history[snap.frame] = snap
+	delete(gc.history, snap.frame-keyframeInterval)
+
+	for clientId, v := range gc.viewers {
+		go gc.sendFn(clientId, model.NewServerMsg(model.ServerMsg_Update, v.update(snap, gc.history), nil, nil))
 	}
+}
 
-	// Add the new player
-	gc.world.addPlayer(newPlayer(req.ClientId, loginRequest.Name, loginRequest.Color))
+// broadcast sends the message to every viewer of the game, the caller must hold the lock
+func (gc *GameController) broadcast(msg *model.ServerMsg) {
+	for clientId := range gc.viewers {
+		go gc.sendFn(clientId, msg)
+	}
+}
 
-	// Change the associated wsConn's status to InGame
-	gc.connStatusFn(req.ClientId, model.Status_InGame)
+// handleResync makes the next world update of the client a keyframe
+func (gc *GameController) handleResync(req *model.ClientRequest) {
+	gc.mu.Lock()
+	v, ok := gc.viewers[req.ClientId]
+	if ok {
+		v.resync()
+	}
+	gc.mu.Unlock()
 
-	// Send the accepted status and the world dump to the player
-	req.Response(model.ResponseStatusAccepted, gc.world.dump())
+	if !ok {
+		req.Response(model.ResponseStatusBadRequest, "Not in game")
+		return
+	}
+	req.Response(model.ResponseStatusOK, "Resync")
 }
@@ -0,0 +1,76 @@
+package game
+
+import "math"
+
+// animState is the movement state of a character, which selects its animation
+type animState int
+
+const (
+	anim_Idle animState = iota
+	anim_Run
+	anim_JumpUp
+	anim_Apex
+	anim_Fall
+	anim_Land
+	anim_Death
+)
+
+const (
+	// animSpeed is the number of game frames a sprite frame of an animation is shown for
+	animSpeed = 4
+	// animFlipOffset is added to the sprite frame when the character is facing left
+	animFlipOffset = 9
+	// apexSpeed is the vertical speed under which a jumping character is at the top of its jump
+	apexSpeed = 1.5
+	// landFrames is the number of game frames the landing animation is shown for
+	landFrames = 4
+)
+
+// animFrames are the sprite frames (facing right) of each animation state, in the order they are played
+var animFrames = map[animState][]int{
+	anim_Idle:   {0},
+	anim_Run:    {0, 1, 2, 3},
+	anim_JumpUp: {4},
+	anim_Apex:   {5},
+	anim_Fall:   {6},
+	anim_Land:   {7},
+	anim_Death:  {8},
+}
+
+// animate derives the character's animation state from its movement, and sets the sprite frame
+func (obj *gameObject) animate(dead bool) {
+	if state := obj.nextAnimState(dead); state != obj.animState {
+		obj.animState = state
+		obj.animTick = 0
+	} else {
+		obj.animTick++
+	}
+
+	frames := animFrames[obj.animState]
+	obj.anim = frames[(obj.animTick/animSpeed)%len(frames)]
+	if obj.flipX {
+		obj.anim += animFlipOffset
+	}
+}
+
+// nextAnimState returns the animation state matching the character's movement
+func (obj *gameObject) nextAnimState(dead bool) animState {
+	switch {
+	case dead:
+		return anim_Death
+	case !obj.onGround && obj.vector.y < -apexSpeed:
+		return anim_JumpUp
+	case !obj.onGround && obj.vector.y <= apexSpeed:
+		return anim_Apex
+	case !obj.onGround:
+		return anim_Fall
+	case obj.animState == anim_JumpUp || obj.animState == anim_Apex || obj.animState == anim_Fall:
+		return anim_Land
+	case obj.animState == anim_Land && obj.animTick < landFrames-1:
+		return anim_Land
+	case math.Abs(obj.vector.x) > minSpeed:
+		return anim_Run
+	default:
+		return anim_Idle
+	}
+}
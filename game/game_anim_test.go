@@ -0,0 +1,75 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+)
+
+func Test_Animate(t *testing.T) {
+	req := require.New(t)
+
+	t_cases := []struct {
+		name     string
+		state    animState
+		tick     int
+		onGround bool
+		x, y     float64
+		flipX    bool
+		dead     bool
+		want     animState
+		wantTick int
+		wantAnim int
+	}{
+		{name: "stand still", state: anim_Idle, tick: 5, onGround: true, want: anim_Idle, wantTick: 6, wantAnim: 0},
+		{name: "start running", state: anim_Idle, tick: 5, onGround: true, x: 2, want: anim_Run, wantTick: 0, wantAnim: 0},
+		{name: "keep running", state: anim_Run, tick: 4, onGround: true, x: 2, want: anim_Run, wantTick: 5, wantAnim: 1},
+		{name: "run facing left", state: anim_Run, tick: 8, onGround: true, x: -2, flipX: true, want: anim_Run, wantTick: 9, wantAnim: 2 + animFlipOffset},
+		{name: "stop running", state: anim_Run, tick: 7, onGround: true, x: minSpeed / 2, want: anim_Idle, wantTick: 0, wantAnim: 0},
+		{name: "jump", state: anim_Run, tick: 3, y: -jumpSpeed, want: anim_JumpUp, wantTick: 0, wantAnim: 4},
+		{name: "reach the apex", state: anim_JumpUp, tick: 10, y: -apexSpeed, want: anim_Apex, wantTick: 0, wantAnim: 5},
+		{name: "fall", state: anim_Apex, tick: 3, y: apexSpeed * 2, want: anim_Fall, wantTick: 0, wantAnim: 6},
+		{name: "walk off a ledge", state: anim_Run, tick: 3, x: 2, y: apexSpeed * 2, want: anim_Fall, wantTick: 0, wantAnim: 6},
+		{name: "land", state: anim_Fall, tick: 9, onGround: true, want: anim_Land, wantTick: 0, wantAnim: 7},
+		{name: "land after a short hop", state: anim_Apex, tick: 2, onGround: true, want: anim_Land, wantTick: 0, wantAnim: 7},
+		{name: "keep landing", state: anim_Land, tick: landFrames - 2, onGround: true, x: 2, want: anim_Land, wantTick: landFrames - 1, wantAnim: 7},
+		{name: "run after landing", state: anim_Land, tick: landFrames - 1, onGround: true, x: 2, want: anim_Run, wantTick: 0, wantAnim: 0},
+		{name: "stand after landing", state: anim_Land, tick: landFrames - 1, onGround: true, want: anim_Idle, wantTick: 0, wantAnim: 0},
+		{name: "die", state: anim_Run, tick: 3, onGround: true, x: 2, dead: true, want: anim_Death, wantTick: 0, wantAnim: 8},
+		{name: "die in the air", state: anim_JumpUp, tick: 3, y: -jumpSpeed, dead: true, want: anim_Death, wantTick: 0, wantAnim: 8},
+		{name: "lie dead facing left", state: anim_Death, tick: 3, onGround: true, flipX: true, dead: true, want: anim_Death, wantTick: 4, wantAnim: 8 + animFlipOffset},
+	}
+
+	for _, t_case := range t_cases {
+		obj := newGameObject("1", "a", "vita", 0, 0, 16)
+		obj.animState, obj.animTick = t_case.state, t_case.tick
+		obj.onGround, obj.flipX = t_case.onGround, t_case.flipX
+		obj.vector = newVector(t_case.x, t_case.y)
+
+		obj.animate(t_case.dead)
+		req.Equal(t_case.want, obj.animState, "The state should be right to %s", t_case.name)
+		req.Equal(t_case.wantTick, obj.animTick, "The tick should be right to %s", t_case.name)
+		req.Equal(t_case.wantAnim, obj.anim, "The sprite frame should be right to %s", t_case.name)
+	}
+}
+
+func Test_AnimateWrap(t *testing.T) {
+	req := require.New(t)
+
+	obj := newGameObject("1", "a", "vita", 0, 0, 16)
+	obj.onGround = true
+	obj.vector = newVector(2, 0)
+
+	var anims []int
+	for i := 0; i < len(animFrames[anim_Run])*animSpeed+1; i++ {
+		obj.animate(false)
+		anims = append(anims, obj.anim)
+	}
+	req.Equal([]int{
+		0, 0, 0, 0,
+		1, 1, 1, 1,
+		2, 2, 2, 2,
+		3, 3, 3, 3,
+		0,
+	}, anims, "Every sprite frame of the run should be shown for animSpeed frames, then the run should start again")
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// botSkills are the abilities of the bots on a difficulty level
+type botSkills struct {
+	// reaction is the number of frames between two decisions
+	reaction int
+	// mistake is the chance (in percent) of a random decision
+	mistake int
+	// dodge makes the bot run away from the players falling on it
+	dodge bool
+	// aim makes the bot jump from a distance where it can land on its target's head
+	aim bool
+}
+
+var botLevels = map[string]botSkills{
+	model.BotLevel_Easy:   {reaction: 15, mistake: 30},
+	model.BotLevel_Normal: {reaction: 8, mistake: 10, dodge: true},
+	model.BotLevel_Hard:   {reaction: 3, mistake: 0, dodge: true, aim: true},
+}
+
+// bot is the AI controlling the input of a player
+type bot struct {
+	skills botSkills
+	// cooldown is the number of frames until the next decision
+	cooldown int
+}
+
+// balanceBots adds a bot while there are fewer players than MinPlayer,
+// and removes one when there are more, as humans join. With no humans in the world there are no bots either.
+// The caller must hold the lock
+func (gw *gameWorld) balanceBots() {
+	skills, enabled := botLevels[gw.rules.BotLevel]
+	humans := gw.humans()
+	bots := len(gw.players) - humans
+	var lastBot *player
+	for _, player := range gw.players {
+		if player.bot != nil {
+			lastBot = player
+		}
+	}
+	wanted := 0
+	if enabled && humans > 0 && humans < gw.minPlayer() {
+		wanted = gw.minPlayer() - humans
+	}
+	switch {
+	case bots < wanted:
+		// A human may play with the name of a bot, that name is skipped
+		gw.lastBot++
+		for gw.nameTaken(fmt.Sprintf("Bot %d", gw.lastBot)) {
+			gw.lastBot++
+		}
+		newBot := newPlayer(fmt.Sprintf("%s%d", model.BotClientIdPrefix, gw.lastBot), fmt.Sprintf("Bot %d", gw.lastBot), "#808080", "")
+		newBot.bot = &bot{skills: skills}
+		log.Debugf("Bot %s joins the game", newBot.name)
+		gw.join(newBot)
+	case bots > wanted:
+		log.Debugf("Bot %s leaves the game", lastBot.name)
+		gw.leave(lastBot)
+	}
+}
+
+// thinkBots lets every bot decide which keys to hold
+func (gw *gameWorld) thinkBots() {
+	for _, player := range gw.players {
+		if player.bot != nil {
+			gw.think(player)
+		}
+	}
+}
+
+// think is the decision of a bot: chase the nearest player and try to land on its head,
+// while dodging the ones falling on it
+func (gw *gameWorld) think(p *player) {
+	if p.bot.cooldown--; p.bot.cooldown > 0 {
+		return
+	}
+	p.bot.cooldown = p.bot.skills.reaction
+	p.input = input{}
+
+	if p.char == nil || p.dead > 0 {
+		return
+	}
+
+	if gw.rng.Intn(100) < p.bot.skills.mistake {
+		p.input.left = gw.rng.Intn(2) == 0
+		p.input.right = !p.input.left
+		p.input.jump = gw.rng.Intn(2) == 0
+		return
+	}
+
+	target := gw.nearestTarget(p)
+	if target == nil {
+		return
+	}
+
+	me, it := p.char, target.char
+	size := float64(me.size)
+	dx, dy := it.x-me.x, it.y-me.y
+
+	dir := 1.0
+	if dx < 0 {
+		dir = -1
+	}
+
+	// The target is falling on the bot, run away
+	if p.bot.skills.dodge && dy < -size/2 && math.Abs(dx) < size*2 && it.vector.y >= 0 {
+		dir = -dir
+		p.input.left, p.input.right = dir < 0, dir > 0
+		p.input.jump = gw.collides(me.x+dir*size/2, me.y, me.size)
+		return
+	}
+
+	if math.Abs(dx) > size/4 {
+		p.input.left, p.input.right = dir < 0, dir > 0
+	}
+
+	// Jump to reach a target above, to get over a wall, or to land on the head of a target nearby
+	p.input.jump = dy < -size || gw.collides(me.x+dir*size/2, me.y, me.size)
+	if p.bot.skills.aim {
+		p.input.jump = p.input.jump || (math.Abs(dx) > size && math.Abs(dx) < size*3 && dy > -size/2)
+	} else {
+		p.input.jump = p.input.jump || (math.Abs(dx) < size*2 && dy > -size/2)
+	}
+}
+
+// nearestTarget returns the closest living player to the given one, or nil if there is none
+func (gw *gameWorld) nearestTarget(p *player) (target *player) {
+	best := math.Inf(1)
+	for _, other := range gw.players {
+		if other == p || other.char == nil || other.dead > 0 {
+			continue
+		}
+		if dist := utils.Distance(p.char.x, p.char.y, other.char.x, other.char.y); dist < best {
+			best, target = dist, other
+		}
+	}
+	return
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_BalanceBots(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.MinPlayer = 3
+	gw := newGameWorld(rules, model.DefaultWorldMap(), 1)
+	balance := func(times int) {
+		for i := 0; i < times; i++ {
+			gw.balanceBots()
+		}
+	}
+
+	balance(5)
+	req.Len(gw.players, 0, "No bots should join without humans")
+
+	gw.addPlayer(newPlayer("a", "Alice", "red", ""))
+	balance(5)
+	req.Len(gw.players, 3, "The bots should fill the world up to MinPlayer")
+	req.Equal(1, gw.humans())
+
+	gw.addPlayer(newPlayer("b", "Bob", "blue", ""))
+	balance(5)
+	req.Len(gw.players, 3, "A bot should leave as a human joins")
+	gw.addPlayer(newPlayer("c", "Carol", "green", ""))
+	balance(5)
+	req.Len(gw.players, 3, "Every bot should leave when there are enough humans")
+	req.Equal(3, gw.humans())
+
+	gw.removePlayer("b")
+	gw.removePlayer("c")
+	balance(5)
+	req.Len(gw.players, 3, "The bots should join again as the humans leave")
+	gw.removePlayer("a")
+	balance(5)
+	req.Len(gw.players, 0, "The bots should leave with the last human")
+
+	gw.rules.BotLevel = model.BotLevel_None
+	gw.addPlayer(newPlayer("a", "Alice", "red", ""))
+	balance(5)
+	req.Len(gw.players, 1, "No bots should join when they are disabled")
+}
+
+func Test_BotName(t *testing.T) {
+	req := require.New(t)
+
+	gw := newGameWorld(model.DefaultConf().WorldRules, model.DefaultWorldMap(), 1)
+	gw.addPlayer(newPlayer("a", "Bot 1", "red", ""))
+	gw.balanceBots()
+
+	req.Len(gw.players, 2, "A bot should join the human")
+	req.Equal("Bot 2", gw.players[1].name, "The bot should skip the name taken by the human")
+	req.Equal(model.BotClientIdPrefix+"2", gw.players[1].clientId)
+}
+
+func Test_BotClientId(t *testing.T) {
+	req := require.New(t)
+
+	gw := newGameWorld(model.DefaultConf().WorldRules, model.DefaultWorldMap(), 1)
+	gw.rules.MinPlayer = 3
+	human := newPlayer("bot-1", "Alice", "red", "")
+	gw.addPlayer(human)
+	gw.balanceBots()
+	gw.balanceBots()
+	req.Len(gw.players, 3, "The bots should join the human")
+
+	gw.addPlayer(newPlayer("b", "Bob", "blue", ""))
+	gw.addPlayer(newPlayer("c", "Carol", "green", ""))
+	gw.balanceBots()
+	gw.balanceBots()
+	req.Equal(3, gw.humans(), "The bots should leave")
+	req.Len(gw.players, 3, "Only the bots should leave")
+	req.Equal(human, gw.players[0], "The human with a bot-like Client ID should stay in the game")
+	req.Len(gw.objects, 3, "Every player should keep its character")
+}
+
+func Test_Think(t *testing.T) {
+	req := require.New(t)
+
+	t_cases := []struct {
+		name   string
+		skills botSkills
+		x, y   float64
+		want   input
+	}{
+		{name: "chase to the right", skills: botLevels[model.BotLevel_Hard], x: 160, y: 48, want: input{right: true}},
+		{name: "chase to the left", skills: botLevels[model.BotLevel_Hard], x: 32, y: 48, want: input{left: true}},
+		{name: "jump next to the target", skills: botSkills{}, x: 112, y: 48, want: input{right: true, jump: true}},
+		{name: "dodge the falling target", skills: botLevels[model.BotLevel_Hard], x: 100, y: 24, want: input{left: true}},
+		{name: "chase the falling target without dodging", skills: botSkills{}, x: 100, y: 24, want: input{jump: true}},
+	}
+
+	for _, t_case := range t_cases {
+		gw := testWorld(
+			"1111111111111",
+			"1000000000001",
+			"1000000000001",
+			"1000000000001",
+			"1111111111111",
+		)
+		b1 := newPlayer("bot-1", "Bot 1", "#808080", "")
+		b1.bot = &bot{skills: t_case.skills}
+		b1.char = newGameObject("1", "bot-1", "vita", 96, 48, 16)
+		target := newPlayer("a", "Alice", "red", "")
+		target.char = newGameObject("2", "a", "vita", t_case.x, t_case.y, 16)
+		gw.players = []*player{b1, target}
+
+		gw.think(b1)
+		req.Equal(t_case.want, b1.input, "The bot should %s", t_case.name)
+	}
+}
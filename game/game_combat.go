@@ -0,0 +1,123 @@
+package game
+
+import (
+	"math"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+)
+
+const (
+	// invulnerableFrames is the number of frames a respawned character cannot be squashed
+	invulnerableFrames = 60
+	// deathFrames is the number of frames a squashed character lies on the ground before respawning
+	deathFrames = 15
+	// bounceSpeed is the upward speed of a character after it squashed someone
+	bounceSpeed = jumpSpeed / 2
+)
+
+// resolveCombat checks every pair of characters for collision. If a character lands
+// on the head of another one, the lower one gets squashed, otherwise they bump each other
+func (gw *gameWorld) resolveCombat() {
+	for i, a := range gw.players {
+		for _, b := range gw.players[i+1:] {
+			if a.char == nil || b.char == nil || a.dead > 0 || b.dead > 0 {
+				continue
+			}
+			switch {
+			case gw.stomps(a, b):
+				gw.squash(a, b)
+			case gw.stomps(b, a):
+				gw.squash(b, a)
+			case a.char.rect().collide(b.char.rect()):
+				gw.bump(a.char, b.char)
+			}
+		}
+	}
+}
+
+// stomps checks if the attacker is landing on the head of the victim. The victim is seen where
+// the attacker saw it, so a lagging attacker can squash a victim who has already moved away
+func (gw *gameWorld) stomps(attacker, victim *player) bool {
+	if victim.invulnerable > 0 || victim.shielded() {
+		return false
+	}
+	if gw.teammates(attacker, victim) && gw.rules.FriendlyFire == model.FriendlyFire_Harmless {
+		return false
+	}
+	x, y := victim.char.x, victim.char.y
+	if past, ok := gw.rewind(attacker, victim); ok {
+		if past.invulnerable > 0 || past.dead > 0 {
+			return false
+		}
+		x, y = past.x, past.y
+	}
+	if !attacker.char.rect().collide(newRect(x, y, victim.char.size, victim.char.size)) {
+		return false
+	}
+	margin := float64(attacker.char.size) / 3
+	return attacker.char.y+margin < y && attacker.char.vector.y >= 0
+}
+
+// squash bounces the attacker up and kills the victim, who respawns after a while,
+// while the round is being played the game mode scores the kill
+func (gw *gameWorld) squash(attacker, victim *player) {
+	log.Debugf("%s squashed %s", attacker.name, victim.name)
+
+	if attacker.input.jump {
+		attacker.char.vector.y = -jumpSpeed
+	} else {
+		attacker.char.vector.y = -bounceSpeed
+	}
+
+	victim.dead = deathFrames
+	victim.char.vector = newVector(0, 0)
+
+	gw.spawnParticles(particle_Fur, victim.char, 8)
+	gw.spawnParticles(particle_Blood, victim.char, 6)
+
+	switch {
+	case gw.round.phase != model.Phase_Playing:
+		return
+	case gw.teammates(attacker, victim):
+		gw.friendlyFire(attacker)
+	default:
+		gw.mode.Kill(gw, attacker, victim)
+	}
+	attacker.stomps++
+	victim.deaths++
+}
+
+// respawn moves the player's character to a safe place and makes it invulnerable for a while,
+// the effect of its item is lost. Without a place to spawn on the character stays where it is
+func (gw *gameWorld) respawn(p *player) {
+	if x, y, ok := gw.spawnPlace(p.char.size); ok {
+		p.char.x, p.char.y = x, y
+	} else {
+		log.Warnf("No place to respawn %s", p.name)
+	}
+	p.char.vector = newVector(0, 0)
+	p.char.onGround, p.char.inWater, p.char.ground = false, false, 0
+	p.char.flipX, p.char.animState, p.char.animTick = false, anim_Idle, 0
+	p.invulnerable = invulnerableFrames
+	p.dead = 0
+	p.effect, p.effectTime = "", 0
+}
+
+// bump pushes two colliding characters apart horizontally, and swaps their horizontal speed
+func (gw *gameWorld) bump(a, b *gameObject) {
+	push := (float64(a.size+b.size)/2 - math.Abs(b.x-a.x)) / 2
+	if a.x > b.x {
+		push = -push
+	}
+	gw.push(a, -push)
+	gw.push(b, push)
+	a.vector.x, b.vector.x = b.vector.x, a.vector.x
+}
+
+// push moves the object horizontally, unless it would end up in a solid tile
+func (gw *gameWorld) push(obj *gameObject, dx float64) {
+	if !gw.collides(obj.x+dx, obj.y, obj.size) {
+		obj.x += dx
+	}
+}
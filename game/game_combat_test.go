@@ -0,0 +1,103 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Stomp(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1000000000001",
+		"1000000000001",
+		"1000000000001",
+		"1111111111111",
+	)
+	gw.rules.PickupRate = 0
+	gw.round.phase = model.Phase_Playing
+	attacker := newPlayer("a", "Alice", "red", "")
+	attacker.char = newGameObject("1", "a", "vita", 20, 20, 16)
+	attacker.char.vector.y = 2
+	victim := newPlayer("b", "Bob", "blue", "")
+	victim.char = newGameObject("2", "b", "vita", 20, 32, 16)
+	gw.players = []*player{attacker, victim}
+	gw.objects = []*gameObject{attacker.char, victim.char}
+
+	gw.update()
+	req.Equal(1, attacker.roundScore, "The stomp should score for the attacker")
+	req.Equal(1, attacker.totalScore, "The stomp should add to the total score of the attacker")
+	req.Equal(deathFrames, victim.dead, "The victim should be killed")
+	req.True(attacker.char.vector.y < 0, "The attacker should bounce off the victim")
+
+	for i := 0; i < deathFrames-1; i++ {
+		gw.update()
+	}
+	req.Equal(1, victim.dead, "The victim should lie on the ground until the end of the delay")
+	gw.update()
+	req.Equal(0, victim.dead, "The victim should respawn after the delay")
+	req.Equal(invulnerableFrames, victim.invulnerable, "The respawned victim should be invulnerable")
+
+	// A stomp on the invulnerable victim does nothing
+	attacker.char.x, attacker.char.y = victim.char.x, victim.char.y-12
+	attacker.char.vector.y = 2
+	gw.update()
+	req.Equal(0, victim.dead, "The invulnerable victim should not be killed")
+	req.Equal(1, attacker.roundScore, "The stomp on an invulnerable victim should not score")
+}
+
+func Test_LagCompensation(t *testing.T) {
+	tCases := []struct {
+		name     string
+		lag      int
+		squashed bool
+	}{
+		{name: "Without lag the victim has already moved away", lag: 0, squashed: false},
+		{name: "With lag the attacker lands where it saw the victim", lag: 1, squashed: true},
+	}
+
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			req := require.New(t)
+
+			gw := testWorld(
+				"10000001",
+				"10000001",
+				"10000001",
+				"11111111",
+			)
+			attacker := newPlayer("a", "Alice", "red", "")
+			attacker.char = newGameObject("1", "a", "vita", 90, 32, 16)
+			victim := newPlayer("b", "Bob", "blue", "")
+			victim.char = newGameObject("2", "b", "vita", 20, 32, 16)
+			gw.players = []*player{attacker, victim}
+			gw.objects = []*gameObject{attacker.char, victim.char}
+
+			gw.update()
+
+			// The victim runs away, while the attacker falls on its previous place
+			victim.char.x = 60
+			attacker.char.x, attacker.char.y = 20, 20
+			attacker.char.vector.y = 2
+			attacker.lag = tCase.lag
+
+			gw.update()
+			req.Equal(tCase.squashed, victim.dead > 0, "The victim should be squashed only if the attacker lags")
+		})
+	}
+}
+
+func Test_LagOf(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld("1111")
+	gw.frame = 100
+
+	req.Equal(0, gw.lagOf(0), "Without a reported frame there should be no lag")
+	req.Equal(0, gw.lagOf(100), "The current frame should mean no lag")
+	req.Equal(3, gw.lagOf(97), "The lag should be the number of frames behind the server")
+	req.Equal(gw.rules.MaxRewind, gw.lagOf(10), "The lag should be capped at MaxRewind")
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"github.com/donbattery/bnj/model"
+)
+
+// keyframeInterval is the number of frames between two keyframes sent to a client,
+// this is also the number of snapshots kept to compute the deltas from
+const keyframeInterval = FrameRate * 3
+
+// snapshot is the dumped state of the world's players and objects at a frame
+type snapshot struct {
+	frame   int64
+	players []model.PlayerDump
+	objects []model.GameObjectDump
+}
+
+func newSnapshot(frame int64, players []model.PlayerDump, objects []model.GameObjectDump) *snapshot {
+	return &snapshot{
+		frame:   frame,
+		players: players,
+		objects: objects,
+	}
+}
+
+// keyframe creates a WorldUpdate with every player and object in the snapshot
+func (snap *snapshot) keyframe() *model.WorldUpdate {
+	return &model.WorldUpdate{
+		Frame:        snap.frame,
+		Keyframe:     true,
+		Players:      snap.players,
+		WorldObjects: snap.objects,
+	}
+}
+
+// delta creates a WorldUpdate with only the players and objects which are changed
+// or removed since the base snapshot
+func (snap *snapshot) delta(base *snapshot) *model.WorldUpdate {
+	update := &model.WorldUpdate{
+		Frame:     snap.frame,
+		BaseFrame: base.frame,
+	}
+
+	basePlayers := make(map[string]model.PlayerDump, len(base.players))
+	for _, player := range base.players {
+		basePlayers[player.Name] = player
+	}
+	for _, player := range snap.players {
+		if old, ok := basePlayers[player.Name]; !ok || old != player {
+			update.Players = append(update.Players, player)
+		}
+		delete(basePlayers, player.Name)
+	}
+	for _, player := range base.players {
+		if _, removed := basePlayers[player.Name]; removed {
+			update.RemovedPlayers = append(update.RemovedPlayers, player.Name)
+		}
+	}
+
+	baseObjects := make(map[string]model.GameObjectDump, len(base.objects))
+	for _, obj := range base.objects {
+		baseObjects[obj.Id] = obj
+	}
+	for _, obj := range snap.objects {
+		if old, ok := baseObjects[obj.Id]; !ok || old != obj {
+			update.WorldObjects = append(update.WorldObjects, obj)
+		}
+		delete(baseObjects, obj.Id)
+	}
+	for _, obj := range base.objects {
+		if _, removed := baseObjects[obj.Id]; removed {
+			update.RemovedObjects = append(update.RemovedObjects, obj.Id)
+		}
+	}
+
+	return update
+}
+
+// viewer is the delta compression state of a client watching the world
+type viewer struct {
+	// spectator is true if the client is watching without playing
+	spectator bool
+	// acked is the last frame the client acknowledged, 0 if none
+	acked int64
+	// lastKeyframe is the frame of the last keyframe sent to the client
+	lastKeyframe int64
+}
+
+// update creates the next WorldUpdate for the viewer, it is a keyframe if the viewer
+// needs one, otherwise it is a delta relative to the last acknowledged snapshot
+func (v *viewer) update(snap *snapshot, history map[int64]*snapshot) *model.WorldUpdate {
+	base, ok := history[v.acked]
+	if !ok || snap.frame-v.lastKeyframe >= keyframeInterval {
+		v.lastKeyframe = snap.frame
+		return snap.keyframe()
+	}
+	return snap.delta(base)
+}
+
+// ack records the frame acknowledged by the client, older acknowledgements are ignored
+func (v *viewer) ack(frame int64) {
+	if frame > v.acked {
+		v.acked = frame
+	}
+}
+
+// resync makes the viewer receive a keyframe next time
+func (v *viewer) resync() {
+	v.acked = 0
+}
+
+// snapshot dumps the current state of the world's players and objects
+func (gw *gameWorld) snapshot() *snapshot {
+	gw.mu.RLock()
+	defer gw.mu.RUnlock()
+
+	players := gw.playerDumps()
+
+	var objects []model.GameObjectDump
+	for _, obj := range gw.objects {
+		objects = append(objects, obj.dump())
+	}
+
+	return newSnapshot(gw.frame, players, objects)
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Delta(t *testing.T) {
+	req := require.New(t)
+
+	base := newSnapshot(10,
+		[]model.PlayerDump{{Name: "Alice"}, {Name: "Bob"}},
+		[]model.GameObjectDump{{Id: "1", X: 10}, {Id: "2", X: 20}, {Id: "3", X: 30}},
+	)
+	next := newSnapshot(11,
+		[]model.PlayerDump{{Name: "Alice", RoundScore: 1}, {Name: "Carol"}},
+		[]model.GameObjectDump{{Id: "1", X: 10}, {Id: "3", X: 31}, {Id: "4", X: 40}},
+	)
+
+	delta := next.delta(base)
+	req.Equal(int64(11), delta.Frame, "The delta should be at the frame of the snapshot")
+	req.Equal(int64(10), delta.BaseFrame, "The delta should be relative to the base frame")
+	req.False(delta.Keyframe, "The delta should not be a keyframe")
+	req.Equal([]model.PlayerDump{{Name: "Alice", RoundScore: 1}, {Name: "Carol"}}, delta.Players, "The delta should hold the changed and new players")
+	req.Equal([]string{"Bob"}, delta.RemovedPlayers, "The delta should hold the removed players")
+	req.Equal([]model.GameObjectDump{{Id: "3", X: 31}, {Id: "4", X: 40}}, delta.WorldObjects, "The delta should hold the changed and new objects")
+	req.Equal([]string{"2"}, delta.RemovedObjects, "The delta should hold the removed objects")
+
+	history := map[int64]*snapshot{base.frame: base}
+	v := &viewer{}
+	req.True(v.update(base, history).Keyframe, "A new viewer should get a keyframe")
+	v.ack(base.frame)
+	req.False(v.update(next, history).Keyframe, "A viewer should get a delta relative to its acknowledged frame")
+	v.resync()
+	req.True(v.update(next, history).Keyframe, "A resynced viewer should get a keyframe")
+	v.ack(base.frame)
+	req.True(v.update(newSnapshot(base.frame+keyframeInterval+1, nil, nil), history).Keyframe, "A viewer should get a keyframe periodically")
+}
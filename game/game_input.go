@@ -0,0 +1,59 @@
+package game
+
+import (
+	"github.com/donbattery/bnj/model"
+)
+
+const (
+	// runAccel is the horizontal acceleration of a character while a direction key is held
+	runAccel = 0.6
+	// maxRunSpeed caps the horizontal speed a character can reach by running
+	maxRunSpeed = 3.0
+	// jumpSpeed is the initial upward speed of a jump
+	jumpSpeed = 8.0
+)
+
+// input is the set of keys held by a player
+type input struct {
+	left  bool
+	right bool
+	jump  bool
+}
+
+// apply updates the held keys according to a control notification
+func (in *input) apply(ctl *model.ControlNotify) {
+	pressed := ctl.ControlType == model.Control_KeyDown
+	switch ctl.ControlKey {
+	case model.Key_Left:
+		in.left = pressed
+	case model.Key_Right:
+		in.right = pressed
+	case model.Key_Jump:
+		in.jump = pressed
+	}
+}
+
+// direction returns -1 if only left, 1 if only right is held, otherwise 0
+func (in *input) direction() float64 {
+	switch {
+	case in.left && !in.right:
+		return -1
+	case in.right && !in.left:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// steer changes the character's vector according to the player's held keys,
+// a jump starts with the given upward speed
+func (in *input) steer(char *gameObject, jumpSpeed float64) {
+	if dir := in.direction(); dir != 0 {
+		char.vector.x = clamp(char.vector.x+dir*runAccel, maxRunSpeed)
+		char.flipX = dir < 0
+	}
+	if in.jump && (char.onGround || char.inWater) {
+		char.vector.y = -jumpSpeed
+		char.onGround = false
+	}
+}
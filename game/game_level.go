@@ -0,0 +1,45 @@
+package game
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// loadLevel reads the level with the given name from the levels bucket, and validates it
+func loadLevel(db model.DBConn, name string) (level model.Level, err error) {
+	keyChain := utils.Chain("levels", name)
+	if db.GetType(keyChain) != "Key" {
+		return level, errors.Errorf("Level %s does not exist", name)
+	}
+	if err = db.Get(keyChain, &level); err != nil {
+		return level, errors.Wrapf(err, "Failed to load level %s", name)
+	}
+	if err = level.Validate(); err != nil {
+		return level, errors.Wrapf(err, "Invalid level %s", name)
+	}
+	return level, nil
+}
+
+// spawnPlace returns a safe place for a character, one of the spawn points of the map if it has any.
+// If there is no safe place a spawn point is used anyway, without spawn points the place is not found
+func (gw *gameWorld) spawnPlace(size int) (x float64, y float64, ok bool) {
+	spawns := gw.worldMap.Spawns
+	start := 0
+	if len(spawns) > 0 {
+		start = gw.rng.Intn(len(spawns))
+	}
+	for i := range spawns {
+		spawn := spawns[(start+i)%len(spawns)]
+		x, y = float64(spawn.Col*gw.rules.BlockSize), float64(spawn.Row*gw.rules.BlockSize)
+		if gw.isSafe(x, y, size) {
+			return x, y, true
+		}
+	}
+	if x, y, ok = gw.findSafePlace(size, safePlaceTries); ok || len(spawns) == 0 {
+		return
+	}
+	spawn := spawns[start]
+	return float64(spawn.Col * gw.rules.BlockSize), float64(spawn.Row * gw.rules.BlockSize), true
+}
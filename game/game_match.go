@@ -0,0 +1,172 @@
+package game
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// matchRecord returns the result of the won round, which lasted for the given number of frames.
+// Every participant of the round is recorded, the ones who left it as well,
+// and the results of a player who left and joined again are added up
+func (gw *gameWorld) matchRecord(frames int64) *model.MatchRecord {
+	record := &model.MatchRecord{
+		Round:      gw.round.number,
+		Level:      gw.rules.Level,
+		GameMode:   gw.mode.Name(),
+		Duration:   int(frames / FrameRate),
+		Winner:     gw.round.winner,
+		WinnerTeam: gw.round.winnerTeam,
+	}
+	recorded := make(map[string]int)
+	for _, player := range gw.participants {
+		i, ok := recorded[player.name]
+		if !ok {
+			i = len(record.Players)
+			recorded[player.name] = i
+			record.Players = append(record.Players, model.MatchPlayer{Name: player.name})
+		}
+		mp := &record.Players[i]
+		mp.Team, mp.Bot = player.team, player.bot != nil
+		mp.Score += player.roundScore
+		mp.Stomps += player.stomps
+		mp.Deaths += player.deaths
+	}
+	return record
+}
+
+// saveMatch stores the finished round in the matches bucket under a new ID: its record if it is won,
+// and its replay if the replays are kept, the replays of the older matches over the limit are deleted.
+// The stats of the human players of a won round are updated in the users bucket
+func saveMatch(db model.DBConn, replay model.Replay, limit int) (string, error) {
+	record := replay.Record
+	if record == nil && limit <= 0 {
+		return "", nil
+	}
+
+	// The IDs are sortable by their creation time
+	id, created := xid.New().String(), time.Now()
+	if err := db.CreateBucket(utils.Chain("matches", id)); err != nil {
+		return "", errors.Wrapf(err, "Cannot create match %s", id)
+	}
+
+	if record != nil {
+		record.Id, record.Room, record.Created = id, replay.Room, created
+		if err := db.Set(utils.Chain("matches", id, "record"), record); err != nil {
+			return id, errors.Wrapf(err, "Cannot save the record of match %s", id)
+		}
+		if err := updateStats(db, record); err != nil {
+			return id, errors.Wrapf(err, "Cannot update the stats of the players of match %s", id)
+		}
+	}
+
+	if limit > 0 {
+		// The record is stored on its own
+		replay.Id, replay.Created, replay.Record = id, created, nil
+		if err := db.Set(utils.Chain("matches", id, "replay"), replay); err != nil {
+			return id, errors.Wrapf(err, "Cannot save the replay of match %s", id)
+		}
+	}
+
+	return id, deleteReplays(db, limit)
+}
+
+// deleteReplays deletes the replays of the oldest matches over the limit, their records are kept,
+// the matches without a record (the aborted rounds) are deleted entirely
+func deleteReplays(db model.DBConn, limit int) error {
+	ids, err := db.BucketKeys("matches")
+	if err != nil {
+		return errors.Wrap(err, "Cannot list the matches")
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	kept := 0
+	for _, id := range ids {
+		keyChain := utils.Chain("matches", id, "replay")
+		if db.GetType(keyChain) != "Key" {
+			continue
+		}
+		if kept++; kept <= limit {
+			continue
+		}
+		if db.GetType(utils.Chain("matches", id, "record")) != "Key" {
+			log.Debugf("Deleting match %s without a record over the limit of %d replays", id, limit)
+			if err := db.DeleteBucket(utils.Chain("matches", id)); err != nil {
+				return errors.Wrapf(err, "Cannot delete match %s", id)
+			}
+			continue
+		}
+		log.Debugf("Deleting the replay of match %s over the limit of %d replays", id, limit)
+		if err := db.Del(keyChain); err != nil {
+			return errors.Wrapf(err, "Cannot delete the replay of match %s", id)
+		}
+	}
+	return nil
+}
+
+// updateStats adds the results of the human players of the match to their stats
+func updateStats(db model.DBConn, record *model.MatchRecord) error {
+	for _, player := range record.Players {
+		if player.Bot {
+			continue
+		}
+		stats, err := loadStats(db, player.Name)
+		if err != nil {
+			return err
+		}
+		stats.Stomps += player.Stomps
+		stats.Deaths += player.Deaths
+		stats.Games++
+		if player.Name == record.Winner || (record.WinnerTeam != "" && player.Team == record.WinnerTeam) {
+			stats.Wins++
+		}
+		stats.TotalScore += player.Score
+		stats.LastPlayed = record.Created
+		if err := db.Set(utils.Chain("users", player.Name), stats); err != nil {
+			return errors.Wrapf(err, "Cannot save the stats of %s", player.Name)
+		}
+	}
+	return nil
+}
+
+// loadStats reads the stats of the player from the users bucket, a player without stats gets empty ones
+func loadStats(db model.DBConn, name string) (stats model.PlayerStats, err error) {
+	keyChain := utils.Chain("users", name)
+	if db.GetType(keyChain) != "Key" {
+		return model.PlayerStats{Name: name}, nil
+	}
+	if err = db.Get(keyChain, &stats); err != nil {
+		return stats, errors.Wrapf(err, "Failed to load the stats of %s", name)
+	}
+	return stats, nil
+}
+
+// recentMatches returns the records of the last matches, the newest first
+func recentMatches(db model.DBConn, count int) ([]model.MatchRecord, error) {
+	ids, err := db.BucketKeys("matches")
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot list the matches")
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	records := []model.MatchRecord{}
+	for _, id := range ids {
+		if len(records) >= count {
+			break
+		}
+		keyChain := utils.Chain("matches", id, "record")
+		if db.GetType(keyChain) != "Key" {
+			continue
+		}
+		var record model.MatchRecord
+		if err := db.Get(keyChain, &record); err != nil {
+			return records, errors.Wrapf(err, "Failed to load the record of match %s", id)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/database"
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+func Test_SaveMatch(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "bnj")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	db := database.New()
+	req.NoError(db.Init(model.GetDBInitConfig(&model.DataBase{Type: "bolt", URL: filepath.Join(dir, "bnj.db")})))
+	defer db.Close()
+
+	gw := testWorld(
+		"1111111",
+		"1000001",
+		"1000001",
+		"1000001",
+		"1111111",
+	)
+	gw.round.phase = model.Phase_Playing
+	alice := newPlayer("a", "Alice", "red", "")
+	alice.char = newGameObject("1", "a", "vita", 16, 16, 16)
+	bob := newPlayer("b", "Bob", "blue", "")
+	bob.char = newGameObject("2", "b", "vita", 64, 16, 16)
+	b1 := newPlayer("bot-1", "Bot 1", "#808080", "")
+	b1.bot = &bot{}
+	gw.players = []*player{alice, bob, b1}
+	gw.participants = []*player{alice, bob, b1}
+
+	gw.squash(alice, bob)
+	gw.squash(alice, bob)
+	gw.squash(bob, alice)
+	// Bob leaves and joins again before the end of the round
+	gw.removePlayer("b")
+	gw.addPlayer(newPlayer("b2", "Bob", "blue", ""))
+	gw.removePlayer("b2")
+	gw.round.winner = alice.name
+	record := gw.matchRecord(FrameRate * 90)
+	req.Equal(90, record.Duration, "The duration should be in seconds")
+	req.Len(record.Players, 3, "Every participant of the round should be recorded once")
+	req.Equal(model.MatchPlayer{Name: "Alice", Score: 2, Stomps: 2, Deaths: 1}, record.Players[0], "The results of the round should be recorded")
+	req.Equal(model.MatchPlayer{Name: "Bob", Score: 1, Stomps: 1, Deaths: 2}, record.Players[1], "The results of the players who left should be recorded")
+
+	// An aborted round is saved first, it has nothing to keep once its replay is over the limit
+	aborted, err := saveMatch(db, model.Replay{Room: "main"}, 2)
+	req.NoError(err)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		record := *record
+		id, err := saveMatch(db, model.Replay{Room: "main", Record: &record}, 2)
+		req.NoError(err)
+		ids = append(ids, id)
+	}
+	id, err := saveMatch(db, model.Replay{Room: "main"}, 2)
+	req.NoError(err)
+	ids = append(ids, id)
+
+	req.Equal("Undefined", db.GetType(utils.Chain("matches", ids[0], "replay")), "The oldest replays over the limit should be deleted")
+	req.Equal("Key", db.GetType(utils.Chain("matches", ids[0], "record")), "The records of the old matches should be kept")
+	req.Equal("Undefined", db.GetType(utils.Chain("matches", ids[3], "record")), "An aborted round should not have a record")
+	req.Equal("Undefined", db.GetType(utils.Chain("matches", aborted)), "The aborted rounds over the limit should be deleted")
+	matches, err := db.BucketKeys("matches")
+	req.NoError(err)
+	req.Len(matches, 4, "Only the matches with a record or a kept replay should be stored")
+
+	records, err := recentMatches(db, 2)
+	req.NoError(err)
+	req.Len(records, 2, "The number of recent matches should be limited")
+	req.Equal(ids[2], records[0].Id, "The newest match should be the first")
+
+	stats, err := loadStats(db, "Alice")
+	req.NoError(err)
+	req.Equal(model.PlayerStats{Name: "Alice", Stomps: 6, Deaths: 3, Wins: 3, Games: 3, TotalScore: 6, LastPlayed: stats.LastPlayed}, stats, "The stats of the player should be accumulated")
+	stats, err = loadStats(db, "Bob")
+	req.NoError(err)
+	req.Equal(0, stats.Wins, "The loser should not get a win")
+	req.Equal(3, stats.TotalScore, "The player who left should get the score of the rounds")
+	req.Equal(3, stats.Games, "The player who left should get the games")
+	req.Equal("Undefined", db.GetType(utils.Chain("users", "Bot 1")), "The bots should not get stats")
+}
@@ -0,0 +1,189 @@
+package game
+
+import (
+	"github.com/donbattery/bnj/model"
+)
+
+// GameMode owns the scoring and the win conditions of the rounds
+type GameMode interface {
+	// Name returns the name of the mode, as it is selected in the WorldRules
+	Name() string
+	// StartRound prepares the players for a new round
+	StartRound(gw *gameWorld)
+	// Join prepares a player joining while the round is being played
+	Join(gw *gameWorld, p *player)
+	// Kill scores the attacker squashing the victim while the round is being played
+	Kill(gw *gameWorld, attacker, victim *player)
+	// Update is called every frame while the round is being played
+	Update(gw *gameWorld)
+	// Winner returns the winner of the round, or nil while the round goes on
+	Winner(gw *gameWorld) *player
+}
+
+// newGameMode creates the GameMode with the given name, deathmatch if the name is unknown
+func newGameMode(name string) GameMode {
+	switch name {
+	case model.Mode_KingOfTheHill:
+		return &kingOfTheHill{held: make(map[string]int)}
+	case model.Mode_LastRabbit:
+		return &lastRabbit{}
+	default:
+		return &deathmatch{}
+	}
+}
+
+// targetWinner returns the first player who reached the target score, or nil if there is none.
+// In team mode the team totals are compared to the target score, and the winner is the best player of the team
+func targetWinner(gw *gameWorld) *player {
+	if gw.rules.TargetScore <= 0 {
+		return nil
+	}
+	scores := gw.teamScores()
+	var winner *player
+	for _, player := range gw.players {
+		score := player.roundScore
+		if player.team != "" {
+			score = scores[player.team]
+		}
+		if score < gw.rules.TargetScore {
+			continue
+		}
+		if winner == nil || (gw.side(winner) == gw.side(player) && player.roundScore > winner.roundScore) {
+			winner = player
+		}
+	}
+	return winner
+}
+
+// deathmatch is the classic mode, every squash scores a point, and the first player reaching the target score wins
+type deathmatch struct{}
+
+func (dm *deathmatch) Name() string {
+	return model.Mode_Deathmatch
+}
+
+func (dm *deathmatch) StartRound(gw *gameWorld) {}
+
+func (dm *deathmatch) Join(gw *gameWorld, p *player) {}
+
+func (dm *deathmatch) Kill(gw *gameWorld, attacker, victim *player) {
+	attacker.roundScore++
+	attacker.totalScore++
+}
+
+func (dm *deathmatch) Update(gw *gameWorld) {}
+
+func (dm *deathmatch) Winner(gw *gameWorld) *player {
+	return targetWinner(gw)
+}
+
+// kingOfTheHill scores a point for every second a player (or a team) holds the hill alone,
+// the hill is marked with hill tiles on the map, or it is the middle of the map if there are none
+type kingOfTheHill struct {
+	// held is the number of frames each player (by Client ID) holds the hill for since its last point
+	held map[string]int
+}
+
+// hillSize is the size of the hill (in tiles) in the middle of the maps without hill tiles
+const hillSize = 3
+
+func (koth *kingOfTheHill) Name() string {
+	return model.Mode_KingOfTheHill
+}
+
+func (koth *kingOfTheHill) StartRound(gw *gameWorld) {
+	koth.held = make(map[string]int)
+}
+
+func (koth *kingOfTheHill) Join(gw *gameWorld, p *player) {}
+
+func (koth *kingOfTheHill) Kill(gw *gameWorld, attacker, victim *player) {}
+
+func (koth *kingOfTheHill) Update(gw *gameWorld) {
+	var king *player
+	for _, player := range gw.players {
+		if player.char == nil || player.dead > 0 || !koth.onHill(gw, player.char) {
+			continue
+		}
+		if king != nil && gw.side(king) != gw.side(player) {
+			// The hill is contested, nobody holds it
+			return
+		}
+		if king == nil {
+			king = player
+		}
+	}
+	if king == nil {
+		return
+	}
+	if koth.held[king.clientId]++; koth.held[king.clientId] >= FrameRate {
+		koth.held[king.clientId] = 0
+		king.roundScore++
+		king.totalScore++
+	}
+}
+
+func (koth *kingOfTheHill) Winner(gw *gameWorld) *player {
+	return targetWinner(gw)
+}
+
+// onHill checks if the center of the object is on the hill
+func (koth *kingOfTheHill) onHill(gw *gameWorld, obj *gameObject) bool {
+	x, y := obj.x+float64(obj.size)/2, obj.y+float64(obj.size)/2
+	if gw.hasTile(model.Tile_Hill) {
+		return gw.tileAt(x, y) == model.Tile_Hill
+	}
+	size := float64(hillSize * gw.rules.BlockSize)
+	hill := newRect(float64(gw.rect.width)/2-size/2, float64(gw.rect.height)/2-size/2, int(size), int(size))
+	return hill.collide(newRect(x, y, 0, 0))
+}
+
+// lastRabbit gives every player a number of lives, the ones squashed too many times are out of the round,
+// and the last one (or the last team) standing wins
+type lastRabbit struct{}
+
+func (lr *lastRabbit) Name() string {
+	return model.Mode_LastRabbit
+}
+
+func (lr *lastRabbit) StartRound(gw *gameWorld) {
+	for _, player := range gw.players {
+		player.lives = gw.rules.Lives
+	}
+}
+
+// Join gives the lives to the player joining during the round, otherwise the first squash would eliminate it
+func (lr *lastRabbit) Join(gw *gameWorld, p *player) {
+	p.lives = gw.rules.Lives
+}
+
+func (lr *lastRabbit) Kill(gw *gameWorld, attacker, victim *player) {
+	attacker.roundScore++
+	attacker.totalScore++
+	if victim.lives--; victim.lives <= 0 {
+		gw.eliminate(victim)
+	}
+}
+
+func (lr *lastRabbit) Update(gw *gameWorld) {}
+
+func (lr *lastRabbit) Winner(gw *gameWorld) *player {
+	sides := make(map[string]bool)
+	for _, player := range gw.players {
+		sides[gw.side(player)] = true
+	}
+	if len(sides) < 2 {
+		return nil
+	}
+	var last *player
+	for _, player := range gw.players {
+		if player.char == nil {
+			continue
+		}
+		if last != nil && gw.side(last) != gw.side(player) {
+			return nil
+		}
+		last = player
+	}
+	return last
+}
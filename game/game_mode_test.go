@@ -0,0 +1,79 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_KingOfTheHill(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1111111",
+		"1005501",
+		"1111111",
+	)
+	gw.mode = newGameMode(model.Mode_KingOfTheHill)
+	gw.round.phase = model.Phase_Playing
+	alice := newPlayer("a", "Alice", "red", "")
+	alice.char = newGameObject("1", "a", "vita", 48, 16, 16)
+	bob := newPlayer("b", "Bob", "blue", "")
+	bob.char = newGameObject("2", "b", "vita", 16, 16, 16)
+	gw.players = []*player{alice, bob}
+
+	for i := 0; i < FrameRate; i++ {
+		gw.mode.Update(gw)
+	}
+	req.Equal(1, alice.roundScore, "Holding the hill alone for a second should score a point")
+	req.Equal(0, bob.roundScore, "Standing off the hill should not score")
+
+	bob.char.x = 64
+	for i := 0; i < FrameRate; i++ {
+		gw.mode.Update(gw)
+	}
+	req.Equal(1, alice.roundScore, "Nobody should score on a contested hill")
+	req.Equal(0, bob.roundScore, "Nobody should score on a contested hill")
+}
+
+func Test_LastRabbit(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1111111111111",
+		"1000000000001",
+		"1000000000001",
+		"1000000000001",
+		"1111111111111",
+	)
+	gw.rules.Lives = 2
+	gw.mode = newGameMode(model.Mode_LastRabbit)
+	gw.round.phase = model.Phase_Playing
+	alice := newPlayer("a", "Alice", "red", "")
+	alice.char = newGameObject("1", "a", "vita", 16, 16, 16)
+	bob := newPlayer("b", "Bob", "blue", "")
+	bob.char = newGameObject("2", "b", "vita", 64, 16, 16)
+	gw.players = []*player{alice, bob}
+	gw.objects = []*gameObject{alice.char, bob.char}
+	gw.mode.StartRound(gw)
+
+	gw.squash(alice, bob)
+	req.Equal(1, bob.dump().Lives, "A squash should take a life")
+	req.Nil(gw.mode.Winner(gw), "Nobody should win while both players have lives")
+
+	gw.squash(alice, bob)
+	req.Nil(bob.char, "The player without lives should be out of the round")
+	req.Equal(0, bob.dead, "The eliminated player should not be waiting to respawn")
+	req.Equal(alice, gw.mode.Winner(gw), "The last player standing should win")
+
+	gw.revive()
+	req.NotNil(bob.char, "The eliminated player should get a character for the next round")
+
+	carol := newPlayer("c", "Carol", "green", "")
+	gw.addPlayer(carol)
+	req.Equal(2, carol.dump().Lives, "A player joining during the round should get the lives")
+	gw.squash(alice, carol)
+	req.NotNil(carol.char, "A player joining during the round should survive the first squash")
+}
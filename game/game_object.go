@@ -9,28 +9,45 @@ import (
 type gameObject struct {
 	id       string
 	parentId string
+	// owner is the name of the player the object belongs to
+	owner    string
+	skin     string
 	objType  string
 	anim     int
 	x        float64
 	y        float64
 	flipX    bool
 	flipY    bool
-	vector   *vector
+	size     int
+	onGround bool
+	inWater  bool
+	// ground is the code of the tile the object stands on
+	ground int
+	// animState is the current animation and animTick is the number of frames it is playing for
+	animState animState
+	animTick  int
+	vector    *vector
+	// life is the number of frames left until a particle disappears, 0 for the other objects
+	life int
 }
 
-func newGameObject(id, parentId, objType string, x, y float64) *gameObject {
+func newGameObject(id, parentId, objType string, x, y float64, size int) *gameObject {
 	return &gameObject{
 		id:       id,
 		parentId: parentId,
 		objType:  objType,
 		x:        x,
 		y:        y,
+		size:     size,
 		vector:   newVector(0, 0),
 	}
 }
 
 func (obj *gameObject) dump() model.GameObjectDump {
 	return model.GameObjectDump{
+		Id:      obj.id,
+		Owner:   obj.owner,
+		Skin:    obj.skin,
 		ObjType: obj.objType,
 		Anim:    obj.anim,
 		X:       int(math.Round(obj.x)),
@@ -39,3 +56,12 @@ func (obj *gameObject) dump() model.GameObjectDump {
 		FlipY:   obj.flipY,
 	}
 }
+
+// particle checks if the object is a short living particle
+func (obj *gameObject) particle() bool {
+	return obj.life > 0
+}
+
+func (obj *gameObject) rect() *rect {
+	return newRect(obj.x, obj.y, obj.size, obj.size)
+}
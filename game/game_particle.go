@@ -0,0 +1,79 @@
+package game
+
+import "math"
+
+// The kinds of particles, they are the object types of the particle game objects
+const (
+	particle_Fur    = "fur"
+	particle_Blood  = "blood"
+	particle_Splash = "splash"
+	particle_Dust   = "dust"
+)
+
+// particleKind describes how the particles of a kind look and move
+type particleKind struct {
+	size int
+	// life is the number of frames a particle lives
+	life int
+	// speed is the largest initial speed of a particle
+	speed float64
+	// weight is the portion of the world's gravity applied to a particle
+	weight float64
+}
+
+var particleKinds = map[string]particleKind{
+	particle_Fur:    {size: 2, life: 45, speed: 3, weight: 0.2},
+	particle_Blood:  {size: 2, life: 30, speed: 4, weight: 1},
+	particle_Splash: {size: 2, life: 20, speed: 3, weight: 1},
+	particle_Dust:   {size: 3, life: 15, speed: 1, weight: 0},
+}
+
+// spawnParticles adds the given number of particles of a kind around the center of an object,
+// flying upward in random directions. Particles over the MaxParticles of the rules are not spawned
+func (gw *gameWorld) spawnParticles(kind string, at *gameObject, count int) {
+	pk := particleKinds[kind]
+	count = int(math.Min(float64(count), float64(gw.rules.MaxParticles-gw.particles())))
+	for i := 0; i < count; i++ {
+		obj := newGameObject(gw.nextId(), "", kind, at.x+float64(at.size-pk.size)/2, at.y+float64(at.size-pk.size)/2, pk.size)
+		obj.life = pk.life
+		obj.vector.x = (gw.rng.Float64()*2 - 1) * pk.speed
+		obj.vector.y = -gw.rng.Float64() * pk.speed
+		gw.objects = append(gw.objects, obj)
+	}
+}
+
+// particles returns the number of live particles in the world
+func (gw *gameWorld) particles() (count int) {
+	for _, obj := range gw.objects {
+		if obj.particle() {
+			count++
+		}
+	}
+	return
+}
+
+// moveParticle moves the particle by its vector, and stops it if it hits a solid tile
+func (gw *gameWorld) moveParticle(obj *gameObject) {
+	obj.vector.y = math.Min(obj.vector.y+gw.rules.Gravity*particleKinds[obj.objType].weight, maxFallSpeed)
+	if gw.collides(obj.x+obj.vector.x, obj.y+obj.vector.y, obj.size) {
+		obj.vector = newVector(0, 0)
+		return
+	}
+	obj.x += obj.vector.x
+	obj.y += obj.vector.y
+}
+
+// expireParticles ages the particles, and removes the ones at the end of their life
+// or out of the world
+func (gw *gameWorld) expireParticles() {
+	objects := gw.objects[:0]
+	for _, obj := range gw.objects {
+		if obj.particle() {
+			if obj.life--; obj.life <= 0 || !obj.rect().collide(gw.rect) {
+				continue
+			}
+		}
+		objects = append(objects, obj)
+	}
+	gw.objects = objects
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+)
+
+func Test_Particles(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"100001",
+		"100001",
+		"100001",
+		"111111",
+	)
+	gw.rules.MaxParticles = 5
+	obj := newGameObject("obj", "", "vita", 20, 32, 16)
+	gw.objects = append(gw.objects, obj)
+
+	gw.spawnParticles(particle_Fur, obj, 8)
+	req.Equal(5, gw.particles(), "The particles should be capped at MaxParticles")
+	req.Len(gw.objectDump(), 6, "The particles should be dumped with the other objects")
+
+	for i := 0; i < particleKinds[particle_Fur].life; i++ {
+		gw.update()
+	}
+	req.Equal(0, gw.particles(), "The particles should expire at the end of their life")
+	req.Len(gw.objects, 1, "Only the particles should expire")
+}
@@ -0,0 +1,145 @@
+package game
+
+import (
+	"math"
+
+	"github.com/donbattery/bnj/model"
+)
+
+const (
+	// maxFallSpeed caps the vertical speed (pixels per frame) so objects cannot tunnel through tiles
+	maxFallSpeed = 8.0
+	// minSpeed is the horizontal speed under which an object is considered to stand still
+	minSpeed = 0.05
+	// moveStep is the largest distance (in pixels) an object is moved before checking for collision
+	moveStep = 1.0
+	// edge is subtracted from the far sides of a hitbox, so touching a tile does not count as overlapping it
+	edge = 0.001
+	// waterDrag is the portion of the speed kept every frame in water
+	waterDrag = 0.8
+	// maxSwimSpeed caps the horizontal and vertical speed in water
+	maxSwimSpeed = 2.0
+	// springSpeed is the upward speed of an object bounced by a spring
+	springSpeed = jumpSpeed * 1.5
+)
+
+// applyPhysics integrates a single frame of movement for the object:
+// applies gravity and friction to its vector, then moves it along the X and Y axis
+// resolving collisions against the solid tiles of the WorldMap
+func (gw *gameWorld) applyPhysics(obj *gameObject) {
+	wasInWater := obj.inWater
+	obj.inWater = gw.tileAt(obj.x+float64(obj.size)/2, obj.y+float64(obj.size)/2) == model.Tile_Water
+	if obj.inWater && !wasInWater {
+		gw.spawnParticles(particle_Splash, obj, 6)
+	}
+
+	if obj.inWater {
+		// in water the gravity is reversed, so the object floats up to the surface
+		obj.vector.x = clamp(obj.vector.x*waterDrag, maxSwimSpeed)
+		obj.vector.y = clamp(obj.vector.y*waterDrag-gw.rules.Gravity, maxSwimSpeed)
+	} else {
+		obj.vector.y = math.Min(obj.vector.y+gw.rules.Gravity, maxFallSpeed)
+	}
+
+	if obj.onGround && obj.ground != model.Tile_Ice {
+		obj.vector.x *= 1 - gw.rules.Friction
+	}
+	if math.Abs(obj.vector.x) < minSpeed {
+		obj.vector.x = 0
+	}
+
+	gw.moveX(obj)
+	gw.moveY(obj)
+}
+
+// moveX moves the object horizontally by its vector, stopping at the first solid tile
+func (gw *gameWorld) moveX(obj *gameObject) {
+	remaining := obj.vector.x
+	for remaining != 0 {
+		step := clamp(remaining, moveStep)
+		if gw.collides(obj.x+step, obj.y, obj.size) {
+			obj.x = gw.snap(obj.x+step, obj.size, step > 0)
+			obj.vector.x = 0
+			return
+		}
+		obj.x += step
+		remaining -= step
+	}
+}
+
+// moveY moves the object vertically by its vector, stopping at the first solid tile
+// if the object is stopped while falling it is on the ground
+func (gw *gameWorld) moveY(obj *gameObject) {
+	obj.onGround = false
+	remaining := obj.vector.y
+	for remaining != 0 {
+		step := clamp(remaining, moveStep)
+		if gw.collides(obj.x, obj.y+step, obj.size) {
+			obj.y = gw.snap(obj.y+step, obj.size, step > 0)
+			obj.vector.y = 0
+			if step > 0 {
+				gw.land(obj)
+			}
+			return
+		}
+		obj.y += step
+		remaining -= step
+	}
+}
+
+// land puts the object on the ground and remembers the tile under it,
+// unless the tile is a spring which bounces the object up
+func (gw *gameWorld) land(obj *gameObject) {
+	obj.ground = gw.tileAt(obj.x+float64(obj.size)/2, obj.y+float64(obj.size)+edge)
+	if obj.ground == model.Tile_Spring {
+		obj.vector.y = -springSpeed
+		gw.spawnParticles(particle_Dust, obj, 4)
+		return
+	}
+	obj.onGround = true
+}
+
+// snap aligns a colliding coordinate to the edge of the tile it runs into
+func (gw *gameWorld) snap(pos float64, size int, forward bool) float64 {
+	block := float64(gw.rules.BlockSize)
+	if forward {
+		return math.Floor((pos+float64(size))/block)*block - float64(size)
+	}
+	return (math.Floor(pos/block) + 1) * block
+}
+
+// collides checks if a square with the given position and size overlaps any solid tile
+func (gw *gameWorld) collides(x, y float64, size int) bool {
+	for _, offY := range gw.offsets(size) {
+		for _, offX := range gw.offsets(size) {
+			if isSolid(gw.worldMap.GetFloat(x+offX, y+offY, gw.rules.BlockSize)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// offsets returns the points along a side of the given size
+// which are needed to be checked to cover every tile the side touches
+func (gw *gameWorld) offsets(size int) (offsets []float64) {
+	for off := 0; off < size; off += gw.rules.BlockSize {
+		offsets = append(offsets, float64(off))
+	}
+	return append(offsets, float64(size)-edge)
+}
+
+// tileAt returns the code of the tile at the given position
+func (gw *gameWorld) tileAt(x, y float64) int {
+	return gw.worldMap.GetFloat(x, y, gw.rules.BlockSize)
+}
+
+// isSolid checks if a tile code is blocking movement
+func isSolid(tile int) bool {
+	return model.SolidTile(tile)
+}
+
+// clamp limits the value between -limit and limit
+func clamp(value, limit float64) float64 {
+	return math.Max(-limit, math.Min(limit, value))
+}
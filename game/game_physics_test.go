@@ -0,0 +1,137 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func testWorld(rows ...string) *gameWorld {
+	rules := model.DefaultConf().WorldRules
+	rules.BlockSize = 16
+	return newGameWorld(rules, model.WorldMap{Background: "black", Rows: rows}, 1)
+}
+
+func Test_Solid(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"100001",
+		"100001",
+		"100001",
+		"111111",
+	)
+	obj := newGameObject("obj", "", "vita", 20, 0, 16)
+	gw.objects = append(gw.objects, obj)
+
+	for i := 0; i < 60; i++ {
+		gw.update()
+	}
+
+	req.Equal(32.0, obj.y, "The object should land on top of the solid tiles")
+	req.True(obj.onGround, "The object should stand on the ground")
+	req.Equal(model.Tile_Solid, obj.ground, "The object should stand on a solid tile")
+}
+
+func Test_Water(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"100001",
+		"100001",
+		"122221",
+		"122221",
+		"122221",
+		"111111",
+	)
+	obj := newGameObject("obj", "", "vita", 20, 64, 16)
+	obj.vector.x = maxRunSpeed
+	gw.objects = append(gw.objects, obj)
+
+	gw.update()
+	req.True(obj.inWater, "The object should be in the water")
+	req.True(obj.vector.x <= maxSwimSpeed, "The water should slow down the object")
+
+	for i := 0; i < 120; i++ {
+		gw.update()
+		req.False(obj.onGround, "The object should never reach the bottom of the water")
+	}
+
+	req.InDelta(32.0, obj.y, 16.0, "The object should float at the surface of the water")
+}
+
+func Test_Ice(t *testing.T) {
+	req := require.New(t)
+
+	t_cases := []struct {
+		ground   string
+		tile     int
+		minSlide float64
+		maxSlide float64
+	}{
+		{
+			ground:   "1111111111111111",
+			tile:     model.Tile_Solid,
+			minSlide: 0,
+			maxSlide: 16,
+		},
+		{
+			ground:   "1333333333333331",
+			tile:     model.Tile_Ice,
+			minSlide: 64,
+			maxSlide: 256,
+		},
+	}
+
+	for _, t_case := range t_cases {
+		gw := testWorld(
+			"1000000000000001",
+			t_case.ground,
+		)
+		obj := newGameObject("obj", "", "vita", 16, 0, 16)
+		gw.objects = append(gw.objects, obj)
+		gw.update()
+		req.True(obj.onGround, "The object should stand on the ground")
+		req.Equal(t_case.tile, obj.ground, "The object should stand on tile %c", t_case.tile)
+
+		obj.vector.x = maxRunSpeed
+		for i := 0; i < 30; i++ {
+			gw.update()
+		}
+
+		req.True(obj.x-16 >= t_case.minSlide && obj.x-16 <= t_case.maxSlide,
+			"The object should slide between %f and %f pixels on tile %c, but slid %f", t_case.minSlide, t_case.maxSlide, t_case.tile, obj.x-16)
+	}
+}
+
+func Test_Spring(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"100001",
+		"100001",
+		"100001",
+		"100001",
+		"100001",
+		"100001",
+		"100001",
+		"144441",
+	)
+	obj := newGameObject("obj", "", "vita", 32, 80, 16)
+	gw.objects = append(gw.objects, obj)
+
+	highest := obj.y
+	landed := false
+	for i := 0; i < 60; i++ {
+		gw.update()
+		if obj.y < highest {
+			highest = obj.y
+		}
+		landed = landed || obj.onGround
+	}
+
+	req.False(landed, "The object should never stand on a spring")
+	req.True(highest < 32, "The spring should launch the object higher than where it was dropped, but it reached %f", highest)
+}
@@ -0,0 +1,104 @@
+package game
+
+import (
+	"math"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+)
+
+const (
+	// jetpackThrust is the upward acceleration of a character with a jetpack while the jump key is held
+	jetpackThrust = 0.9
+	// maxJetpackSpeed caps the upward speed a jetpack can reach
+	maxJetpackSpeed = jumpSpeed / 2
+	// bootsBoost multiplies the jump speed of a character wearing bunny boots
+	bootsBoost = 1.4
+	// pickupTries is the number of random places tried for a new item, if none of them is safe the item is not spawned
+	pickupTries = 20
+)
+
+// pickup checks if the object is a collectible item
+func (obj *gameObject) pickup() bool {
+	switch obj.objType {
+	case model.Pickup_Jetpack, model.Pickup_Boots, model.Pickup_Shield:
+		return true
+	}
+	return false
+}
+
+// spawnPickups places a random enabled item on an empty place now and then, while the round is being played.
+// On average an item is spawned every PickupRate seconds, while there are fewer than MaxPickups
+func (gw *gameWorld) spawnPickups() {
+	if gw.round.phase != model.Phase_Playing || len(gw.rules.Pickups) == 0 || gw.rules.PickupRate <= 0 {
+		return
+	}
+	if gw.pickups() >= gw.rules.MaxPickups || gw.rng.Intn(gw.rules.PickupRate*FrameRate) != 0 {
+		return
+	}
+	kind := gw.rules.Pickups[gw.rng.Intn(len(gw.rules.Pickups))]
+	x, y, ok := gw.findSafePlace(gw.rules.BlockSize, pickupTries)
+	if !ok {
+		log.Debugf("No place for pickup %s", kind)
+		return
+	}
+	gw.objects = append(gw.objects, newGameObject(gw.nextId(), "", kind, x, y, gw.rules.BlockSize))
+	log.Debugf("Pickup %s spawned at X %f Y %f", kind, x, y)
+}
+
+// pickups returns the number of items waiting to be collected
+func (gw *gameWorld) pickups() (count int) {
+	for _, obj := range gw.objects {
+		if obj.pickup() {
+			count++
+		}
+	}
+	return
+}
+
+// collectPickups gives the items to the living characters touching them,
+// the effect of the new item replaces the previous one
+func (gw *gameWorld) collectPickups() {
+	for _, player := range gw.players {
+		if player.char == nil || player.dead > 0 {
+			continue
+		}
+		objects := gw.objects[:0]
+		for _, obj := range gw.objects {
+			if obj.pickup() && player.char.rect().collide(obj.rect()) {
+				log.Debugf("%s collected a %s", player.name, obj.objType)
+				player.effect = obj.objType
+				player.effectTime = gw.rules.PickupTime * FrameRate
+				continue
+			}
+			objects = append(objects, obj)
+		}
+		gw.objects = objects
+	}
+}
+
+// applyEffect applies the player's active item to its character, and wears the item off in time
+func (gw *gameWorld) applyEffect(p *player) {
+	if p.effect == "" {
+		return
+	}
+	if p.effect == model.Pickup_Jetpack && p.input.jump && !p.char.onGround && !p.char.inWater && p.char.vector.y > -maxJetpackSpeed {
+		p.char.vector.y = math.Max(p.char.vector.y-jetpackThrust, -maxJetpackSpeed)
+	}
+	if p.effectTime--; p.effectTime <= 0 {
+		p.effect = ""
+	}
+}
+
+// jumpSpeed returns the initial upward speed of the player's jump
+func (p *player) jumpSpeed() float64 {
+	if p.effect == model.Pickup_Boots {
+		return jumpSpeed * bootsBoost
+	}
+	return jumpSpeed
+}
+
+// shielded checks if the player cannot be squashed because of its shield
+func (p *player) shielded() bool {
+	return p.effect == model.Pickup_Shield
+}
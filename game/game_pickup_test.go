@@ -0,0 +1,108 @@
+package game
+
+import (
+	"math"
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Pickups(t *testing.T) {
+	tCases := []struct {
+		kind      string
+		jumpSpeed float64
+		shielded  bool
+	}{
+		{kind: model.Pickup_Jetpack, jumpSpeed: jumpSpeed, shielded: false},
+		{kind: model.Pickup_Boots, jumpSpeed: jumpSpeed * bootsBoost, shielded: false},
+		{kind: model.Pickup_Shield, jumpSpeed: jumpSpeed, shielded: true},
+	}
+
+	for _, tCase := range tCases {
+		t.Run(tCase.kind, func(t *testing.T) {
+			req := require.New(t)
+
+			gw := testWorld(
+				"100001",
+				"100001",
+				"100001",
+				"111111",
+			)
+			gw.rules.PickupTime = 1
+			p := newPlayer("a", "Alice", "red", "")
+			p.char = newGameObject("1", "a", "vita", 20, 32, 16)
+			gw.players = []*player{p}
+			gw.objects = []*gameObject{p.char, newGameObject("2", "", tCase.kind, 28, 32, 16)}
+
+			gw.update()
+			req.Equal(0, gw.pickups(), "The item should be collected")
+			req.Equal(tCase.kind, p.dump().Effect, "The effect of the item should be dumped")
+			req.Equal(tCase.jumpSpeed, p.jumpSpeed(), "Only the boots should change the jump speed")
+			req.Equal(tCase.shielded, p.shielded(), "Only the shield should protect the player")
+
+			for i := 0; i < FrameRate; i++ {
+				gw.update()
+			}
+			req.Empty(p.effect, "The effect should wear off after PickupTime")
+		})
+	}
+}
+
+func Test_Jetpack(t *testing.T) {
+	req := require.New(t)
+
+	heights := make(map[string]float64)
+	for _, effect := range []string{"", model.Pickup_Jetpack} {
+		gw := testWorld(
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"100001",
+			"111111",
+		)
+		p := newPlayer("a", "Alice", "red", "")
+		p.char = newGameObject("1", "a", "vita", 20, 128, 16)
+		p.effect, p.effectTime = effect, FrameRate*2
+		gw.players = []*player{p}
+		gw.objects = []*gameObject{p.char}
+
+		gw.update()
+		p.input.jump = true
+		heights[effect] = p.char.y
+		for i := 0; i < 40; i++ {
+			gw.update()
+			heights[effect] = math.Min(heights[effect], p.char.y)
+		}
+	}
+	req.True(heights[model.Pickup_Jetpack] < heights[""], "The jetpack should lift the character higher than a jump")
+}
+
+func Test_NoPlaceForPickup(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1111111",
+		"1000001",
+		"1000001",
+		"1111111",
+	)
+	gw.rules.PickupRate = 1
+	gw.round.phase = model.Phase_Playing
+	gw.objects = []*gameObject{
+		newGameObject("1", "a", "vita", 16, 32, 16),
+		newGameObject("2", "b", "vita", 48, 32, 16),
+		newGameObject("3", "c", "vita", 80, 32, 16),
+	}
+
+	for i := 0; i < FrameRate*10; i++ {
+		gw.spawnPickups()
+	}
+	req.Equal(0, gw.pickups(), "No item should be spawned without a safe place")
+}
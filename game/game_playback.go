@@ -0,0 +1,201 @@
+package game
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// playback plays the replay of a match back to a client, the replay is simulated again
+// and the client receives the same world updates as the viewers of a live game
+type playback struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	clientId string
+	replay   model.Replay
+	sim      *Simulation
+	step     time.Duration
+	speed    float64
+	paused   bool
+	// progress is the part of the next frame already played back, it grows with the speed on every tick
+	progress float64
+	viewer   *viewer
+	history  map[int64]*snapshot
+	sendFn   func(clientId string, msg *model.ServerMsg)
+}
+
+// newPlayback creates the playback of the replay for the client, it is played with the given speed multiplier,
+// and a frame is simulated once every step at normal speed
+func newPlayback(ctx context.Context, clientId string, replay model.Replay, speed float64, step time.Duration, sendFn func(clientId string, msg *model.ServerMsg)) *playback {
+	ctx, cancel := context.WithCancel(ctx)
+	return &playback{
+		ctx:      ctx,
+		cancel:   cancel,
+		clientId: clientId,
+		replay:   replay,
+		sim:      NewReplaySimulation(replay),
+		step:     step,
+		speed:    speed,
+		viewer:   &viewer{spectator: true},
+		history:  make(map[int64]*snapshot),
+		sendFn:   sendFn,
+	}
+}
+
+// loadReplay reads the replay of the match with the given ID from the matches bucket
+func loadReplay(db model.DBConn, id string) (replay model.Replay, err error) {
+	keyChain := utils.Chain("matches", id, "replay")
+	if db.GetType(keyChain) != "Key" {
+		return replay, errors.Errorf("Replay of match %s does not exist", id)
+	}
+	if err = db.Get(keyChain, &replay); err != nil {
+		return replay, errors.Wrapf(err, "Failed to load the replay of match %s", id)
+	}
+	return replay, nil
+}
+
+func (pb *playback) start() {
+	go pb.run()
+}
+
+func (pb *playback) stop() {
+	pb.cancel()
+}
+
+func (pb *playback) run() {
+	tick := time.NewTicker(pb.step)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-pb.ctx.Done():
+			return
+		case <-tick.C:
+			pb.tick()
+		}
+	}
+}
+
+// tick plays back as many frames as the speed allows, and sends the last one to the client,
+// the playback is paused at the end of the replay
+func (pb *playback) tick() {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	if pb.paused {
+		return
+	}
+	stepped := false
+	for pb.progress += pb.speed; pb.progress >= 1; pb.progress-- {
+		if pb.ended() {
+			pb.paused, pb.progress = true, 0
+			break
+		}
+		pb.sim.advance()
+		stepped = true
+	}
+	if stepped {
+		pb.send()
+	}
+}
+
+// control applies a playback control and returns the new state of the playback
+func (pb *playback) control(ctl model.PlaybackControl) model.PlaybackInfo {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	switch ctl.Action {
+	case model.Playback_Pause:
+		pb.paused = true
+	case model.Playback_Resume:
+		// Resuming at the end starts the replay again
+		if pb.ended() {
+			pb.seek(0)
+		}
+		pb.paused = false
+	case model.Playback_Seek:
+		pb.seek(ctl.Frame)
+	case model.Playback_Speed:
+		pb.speed = ctl.Speed
+	}
+	return pb.info()
+}
+
+// ack records the last world update frame received by the client
+func (pb *playback) ack(frame int64) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	pb.viewer.ack(frame)
+}
+
+// dump returns the current state of the replayed world
+func (pb *playback) dump() model.GameWorldDump {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	return pb.sim.Dump()
+}
+
+// seek simulates the replay to the given frame (counted from the start of the round) and sends it to the client,
+// seeking backwards simulates the replay again from the start. The caller must hold the lock
+func (pb *playback) seek(frame int64) {
+	if frame > pb.replay.Frames {
+		frame = pb.replay.Frames
+	}
+	target := pb.replay.Frame + frame
+	if target < pb.sim.Frame() {
+		pb.sim = NewReplaySimulation(pb.replay)
+	}
+	for pb.sim.Frame() < target {
+		pb.sim.advance()
+	}
+	// The messages of the skipped frames are replaced by the state of the round
+	pb.sim.Messages()
+	round := pb.sim.Dump().Round
+	pb.sendFn(pb.clientId, model.NewRoundMsg(&round))
+
+	pb.progress = 0
+	pb.viewer.resync()
+	pb.history = make(map[int64]*snapshot)
+	pb.send()
+}
+
+// send sends the messages of the replayed world and its current state to the client, the caller must hold the lock
+func (pb *playback) send() {
+	for _, msg := range pb.sim.Messages() {
+		pb.sendFn(pb.clientId, msg)
+	}
+
+	// Keep the snapshots the client may acknowledge, drop the older ones
+	snap := pb.sim.world.snapshot()
+	pb.history[snap.frame] = snap
+	for frame := range pb.history {
+		if frame <= snap.frame-keyframeInterval {
+			delete(pb.history, frame)
+		}
+	}
+	pb.sendFn(pb.clientId, model.NewServerMsg(model.ServerMsg_Update, pb.viewer.update(snap, pb.history), nil, nil))
+}
+
+// ended checks if every recorded frame is played back, the caller must hold the lock
+func (pb *playback) ended() bool {
+	return pb.sim.Frame() >= pb.replay.Frame+pb.replay.Frames
+}
+
+// info returns the state of the playback, the caller must hold the lock
+func (pb *playback) info() model.PlaybackInfo {
+	return model.PlaybackInfo{
+		Match:  pb.replay.Id,
+		Frame:  pb.sim.Frame() - pb.replay.Frame,
+		Frames: pb.replay.Frames,
+		Speed:  pb.speed,
+		Paused: pb.paused,
+	}
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Playback(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.BotLevel = model.BotLevel_None
+	sim := NewSimulation(rules, model.DefaultWorldMap(), 3)
+	req.NoError(sim.AddPlayer("a", "Alice", "red"))
+	req.NoError(sim.AddPlayer("b", "Bob", "blue"))
+	sim.Control(120, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right})
+	sim.Step(200)
+	sim.RemovePlayer("b")
+	sim.Step(1)
+	replays := sim.Replays()
+	req.Len(replays, 1)
+	replay := replays[0]
+
+	var sent []*model.ServerMsg
+	pb := newPlayback(context.Background(), "v", replay, 2, time.Millisecond, func(clientId string, msg *model.ServerMsg) {
+		sent = append(sent, msg)
+	})
+	lastUpdate := func() *model.WorldUpdate {
+		for i := len(sent) - 1; i >= 0; i-- {
+			if sent[i].MsgType == model.ServerMsg_Update {
+				return sent[i].WorldUpdate
+			}
+		}
+		return nil
+	}
+
+	pb.tick()
+	req.Equal(int64(2), pb.info().Frame, "Two frames should be played back in a tick at double speed")
+	req.Equal(replay.Frame+2, lastUpdate().Frame, "The last played back frame should be sent")
+	req.True(lastUpdate().Keyframe, "The first update should be a keyframe")
+
+	info := pb.control(model.PlaybackControl{Action: model.Playback_Pause})
+	req.True(info.Paused, "The playback should be paused")
+	pb.tick()
+	req.Equal(int64(2), pb.info().Frame, "A paused playback should not advance")
+
+	info = pb.control(model.PlaybackControl{Action: model.Playback_Seek, Frame: 50})
+	req.Equal(int64(50), info.Frame, "The playback should seek forward")
+	req.True(lastUpdate().Keyframe, "The client should get a keyframe after seeking")
+	req.Equal(replay.Frame+50, lastUpdate().Frame, "The frame sought to should be sent")
+
+	info = pb.control(model.PlaybackControl{Action: model.Playback_Seek, Frame: 10})
+	req.Equal(int64(10), info.Frame, "The playback should seek backward")
+	info = pb.control(model.PlaybackControl{Action: model.Playback_Seek, Frame: replay.Frames + 100})
+	req.Equal(replay.Frames, info.Frame, "Seeking should stop at the end of the replay")
+
+	info = pb.control(model.PlaybackControl{Action: model.Playback_Resume})
+	req.Equal(int64(0), info.Frame, "Resuming at the end should start the replay again")
+	req.False(info.Paused, "The playback should be resumed")
+
+	pb.control(model.PlaybackControl{Action: model.Playback_Speed, Speed: 0.5})
+	pb.tick()
+	pb.tick()
+	req.Equal(int64(1), pb.info().Frame, "A frame should be played back in two ticks at half speed")
+}
@@ -1,30 +1,67 @@
 package game
 
-import "github.com/donbattery/bnj/model"
+import (
+	"math"
+
+	"github.com/donbattery/bnj/model"
+)
 
 type player struct {
 	clientId   string
 	name       string
 	color      string
+	skin       string
 	roundWins  int
 	roundScore int
 	totalScore int
+	// invulnerable is the number of frames left, while the player cannot be squashed
+	invulnerable int
+	// dead is the number of frames left, while the squashed character lies on the ground before respawning
+	dead  int
+	input input
+	// lastSeq and lastTime are the sequence number and the client time of the last applied control notification
+	lastSeq  int64
+	lastTime int64
+	// lag is the number of frames the player is behind the server according to its last control notification
+	lag int
+	// effect is the collected item the player is using, and effectTime is the number of frames it lasts for
+	effect     string
+	effectTime int
+	// lives is the number of times the player can be squashed in a last rabbit standing round
+	lives int
+	// team is the name of the player's team in team mode
+	team string
+	// stomps and deaths are the number of times the player squashed others and got squashed in the round
+	stomps int
+	deaths int
+	char   *gameObject
+	// bot is the AI of the player, nil for humans
+	bot *bot
 }
 
-func newPlayer(clientId, name, color string) *player {
+func newPlayer(clientId, name, color, skin string) *player {
 	return &player{
 		clientId: clientId,
 		name:     name,
 		color:    color,
+		skin:     skin,
 	}
 }
 
 func (p *player) dump() model.PlayerDump {
 	return model.PlayerDump{
-		Name:       p.name,
-		Color:      p.color,
-		RoundWins:  p.roundWins,
-		RoundScore: p.roundScore,
-		TotalScore: p.totalScore,
+		Name:          p.name,
+		Color:         p.color,
+		Skin:          p.skin,
+		Bot:           p.bot != nil,
+		RoundWins:     p.roundWins,
+		RoundScore:    p.roundScore,
+		TotalScore:    p.totalScore,
+		LastInputSeq:  p.lastSeq,
+		LastInputTime: p.lastTime,
+		Effect:        p.effect,
+		EffectTime:    int(math.Ceil(float64(p.effectTime) / FrameRate)),
+		Lives:         p.lives,
+		Team:          p.team,
 	}
 }
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/donbattery/bnj/model"
+)
+
+// newRecording creates the replay of a round starting with the given seed from the current state of the world,
+// it must be called before the round changes anything
+func (gw *gameWorld) newRecording(seed int64) *model.Replay {
+	replay := &model.Replay{
+		Round:      gw.round.number + 1,
+		Seed:       seed,
+		Frame:      gw.frame,
+		WorldRules: gw.rules,
+		WorldMap:   gw.worldMap,
+		LastId:     gw.lastId,
+		LastBot:    gw.lastBot,
+	}
+	for _, player := range gw.players {
+		replay.Players = append(replay.Players, player.replay())
+	}
+	return replay
+}
+
+// recordControl records the control notification applied before the next frame
+func (gw *gameWorld) recordControl(ctl *model.ControlNotify) {
+	if gw.recording == nil {
+		return
+	}
+	gw.recording.Events = append(gw.recording.Events, model.ReplayEvent{
+		Frame:    gw.frame + 1,
+		ClientId: ctl.ClientId,
+		Key:      ctl.ControlKey,
+		Down:     ctl.ControlType == model.Control_KeyDown,
+		Seen:     ctl.Frame,
+	})
+}
+
+// recordJoin records the human player joining before the next frame, the bots come and go on their own
+func (gw *gameWorld) recordJoin(p *player) {
+	if gw.recording == nil || p.bot != nil {
+		return
+	}
+	join := p.replay()
+	gw.recording.Events = append(gw.recording.Events, model.ReplayEvent{
+		Frame:    gw.frame + 1,
+		ClientId: p.clientId,
+		Join:     &join,
+	})
+}
+
+// recordLeave records the human player leaving before the next frame
+func (gw *gameWorld) recordLeave(p *player) {
+	if gw.recording == nil || p.bot != nil {
+		return
+	}
+	gw.recording.Events = append(gw.recording.Events, model.ReplayEvent{
+		Frame:    gw.frame + 1,
+		ClientId: p.clientId,
+		Leave:    true,
+	})
+}
+
+// stopRecording finishes the replay of the round, and the record of the round if it is won,
+// they are waiting to be saved until the next flush
+func (gw *gameWorld) stopRecording() {
+	if gw.recording == nil {
+		return
+	}
+	gw.recording.Frames = gw.frame - gw.recording.Frame
+	gw.recording.Winner = gw.round.winner
+	// Only the won rounds get into the match history
+	if gw.round.winner != "" {
+		gw.recording.Record = gw.matchRecord(gw.recording.Frames)
+	}
+	gw.replays = append(gw.replays, *gw.recording)
+	gw.recording = nil
+}
+
+// flushReplays returns and clears the finished replays
+func (gw *gameWorld) flushReplays() (replays []model.Replay) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	replays, gw.replays = gw.replays, nil
+	return
+}
+
+// newReplayWorld creates a world in the state the replay starts from, and starts the round of the replay
+func newReplayWorld(replay model.Replay) *gameWorld {
+	gw := newGameWorld(replay.WorldRules, replay.WorldMap, replay.Seed)
+	gw.frame = replay.Frame
+	gw.lastId, gw.lastBot = replay.LastId, replay.LastBot
+	gw.round.number = replay.Round - 1
+	for _, rp := range replay.Players {
+		p := gw.replayedPlayer(rp)
+		gw.players = append(gw.players, p)
+		if p.char != nil {
+			gw.objects = append(gw.objects, p.char)
+		}
+	}
+	gw.startRound(replay.Seed)
+	gw.past.record(gw.frame, gw.players)
+	return gw
+}
+
+// replay returns the state of the player to be recorded
+func (p *player) replay() model.ReplayPlayer {
+	rp := model.ReplayPlayer{
+		ClientId:   p.clientId,
+		Name:       p.name,
+		Color:      p.color,
+		Skin:       p.skin,
+		Team:       p.team,
+		RoundWins:  p.roundWins,
+		TotalScore: p.totalScore,
+		Keys:       p.input.keys(),
+		Lag:        p.lag,
+	}
+	if p.char != nil {
+		rp.Char = p.char.id
+	}
+	if p.bot != nil {
+		rp.Bot, rp.Cooldown = true, p.bot.cooldown
+	}
+	return rp
+}
+
+// replayedPlayer creates the player of the world from its recorded state
+func (gw *gameWorld) replayedPlayer(rp model.ReplayPlayer) *player {
+	p := newPlayer(rp.ClientId, rp.Name, rp.Color, rp.Skin)
+	p.team = rp.Team
+	p.roundWins, p.totalScore = rp.RoundWins, rp.TotalScore
+	p.input = input{
+		left:  strings.Contains(rp.Keys, "l"),
+		right: strings.Contains(rp.Keys, "r"),
+		jump:  strings.Contains(rp.Keys, "j"),
+	}
+	p.lag = rp.Lag
+	if rp.Bot {
+		p.bot = &bot{skills: botLevels[gw.rules.BotLevel], cooldown: rp.Cooldown}
+	}
+	if rp.Char != "" {
+		p.char = newGameObject(rp.Char, p.clientId, "vita", 0, 0, gw.rules.BlockSize)
+		p.char.owner = p.name
+		p.char.skin = p.skin
+	}
+	return p
+}
+
+// keys returns the held keys in the format of the replays
+func (in *input) keys() (keys string) {
+	if in.left {
+		keys += "l"
+	}
+	if in.right {
+		keys += "r"
+	}
+	if in.jump {
+		keys += "j"
+	}
+	return
+}
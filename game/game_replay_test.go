@@ -0,0 +1,109 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Replay(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.MaxPlayer = 3
+	rules.TargetScore = 0
+	rules.BotLevel = model.BotLevel_None
+	rules.PickupRate = 1
+
+	sim := NewSimulation(rules, model.DefaultWorldMap(), 42)
+	req.NoError(sim.AddPlayer("a", "Alice", "red"))
+	req.NoError(sim.AddPlayer("b", "Bob", "blue"))
+	sim.Control(50, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Left})
+
+	dumps := make(map[int64]model.GameWorldDump)
+	record := func(frames int) {
+		start := sim.Frame()
+		for i, dump := range sim.Step(frames) {
+			dumps[start+int64(i)+1] = dump
+		}
+	}
+
+	record(100)
+	for frame := int64(101); frame < 200; frame += 7 {
+		sim.Control(frame, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right, Frame: frame - 2})
+		sim.Control(frame, &model.ControlNotify{ClientId: "b", ControlType: model.Control_KeyDown, ControlKey: model.Key_Jump, Frame: frame - 3})
+		sim.Control(frame+3, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyUp, ControlKey: model.Key_Right, Frame: frame})
+		sim.Control(frame+4, &model.ControlNotify{ClientId: "b", ControlType: model.Control_KeyUp, ControlKey: model.Key_Jump, Frame: frame})
+	}
+	record(50)
+	req.NoError(sim.AddPlayer("c", "Carol", "green"), "A player should join during the round")
+	record(100)
+	sim.RemovePlayer("b")
+	sim.RemovePlayer("c")
+	record(10)
+
+	replays := sim.Replays()
+	req.Len(replays, 1, "The aborted round should be recorded")
+	replay := replays[0]
+	req.Equal(1, replay.Round, "The replay should be of the first round")
+	req.Equal("l", replay.Players[0].Keys, "The held keys should be recorded")
+	req.Equal(int64(160), replay.Frames, "The replay should last until the round is aborted")
+
+	replayed := NewReplaySimulation(replay)
+	for i, dump := range replayed.Step(int(replay.Frames)) {
+		frame := replay.Frame + int64(i) + 1
+		req.Equal(dumps[frame], dump, "The replayed frame %d should be the same as the recorded one", frame)
+	}
+	req.Equal(model.Phase_Waiting, replayed.Dump().Round.Phase, "The replayed round should be aborted as well")
+}
+
+func Test_ReplayWithBots(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.MinPlayer = 3
+	rules.TargetScore = 0
+	rules.BotLevel = model.BotLevel_Hard
+
+	sim := NewSimulation(rules, model.DefaultWorldMap(), 7)
+	req.NoError(sim.AddPlayer("a", "Alice", "red"))
+	sim.Control(30, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right})
+
+	dumps := make(map[int64]model.GameWorldDump)
+	record := func(frames int) {
+		start := sim.Frame()
+		for i, dump := range sim.Step(frames) {
+			dumps[start+int64(i)+1] = dump
+		}
+	}
+
+	record(200)
+	req.Len(sim.Dump().Players, 3, "The bots should fill the world up to MinPlayer")
+	// The humans joining and leaving make the bots leave and join during the round
+	req.NoError(sim.AddPlayer("b", "Bob", "blue"))
+	record(100)
+	req.Len(sim.Dump().Players, 3, "A bot should leave as a human joins")
+	req.NoError(sim.AddPlayer("c", "Carol", "green"))
+	record(100)
+	sim.RemovePlayer("b")
+	record(100)
+	sim.RemovePlayer("c")
+	record(100)
+	req.Len(sim.Dump().Players, 3, "Bots should join as the humans leave")
+	sim.RemovePlayer("a")
+	record(10)
+
+	replays := sim.Replays()
+	req.Len(replays, 1, "The aborted round should be recorded")
+	replay := replays[0]
+	req.Len(replay.Players, 3, "The round should start with the bots")
+	req.True(replay.Players[2].Bot, "The bots should be recorded at the start of the round")
+
+	replayed := NewReplaySimulation(replay)
+	for i, dump := range replayed.Step(int(replay.Frames)) {
+		frame := replay.Frame + int64(i) + 1
+		req.Equal(dumps[frame], dump, "The replayed frame %d should be the same as the recorded one", frame)
+	}
+}
@@ -0,0 +1,82 @@
+package game
+
+// charState is the state of a player's character at a past frame, as far as the combat is concerned
+type charState struct {
+	x            float64
+	y            float64
+	invulnerable int
+	dead         int
+}
+
+// pastState is the state of every character at a frame, by the Client IDs of the players
+type pastState struct {
+	frame int64
+	chars map[string]charState
+}
+
+// rewindBuffer is a ring buffer of the past states of the characters,
+// which lets the combat see the world as the lagging players saw it when they sent their inputs
+type rewindBuffer struct {
+	states []pastState
+}
+
+func newRewindBuffer(window int) *rewindBuffer {
+	return &rewindBuffer{
+		states: make([]pastState, window),
+	}
+}
+
+// record stores the state of the characters at the current frame, overwriting the oldest one
+func (rb *rewindBuffer) record(frame int64, players []*player) {
+	if len(rb.states) == 0 {
+		return
+	}
+	chars := make(map[string]charState, len(players))
+	for _, player := range players {
+		if player.char == nil {
+			continue
+		}
+		chars[player.clientId] = charState{
+			x:            player.char.x,
+			y:            player.char.y,
+			invulnerable: player.invulnerable,
+			dead:         player.dead,
+		}
+	}
+	rb.states[frame%int64(len(rb.states))] = pastState{frame: frame, chars: chars}
+}
+
+// at returns the state of the player's character at the given frame,
+// false if the frame is not in the buffer or the player had no character at the time
+func (rb *rewindBuffer) at(frame int64, clientId string) (charState, bool) {
+	if len(rb.states) == 0 || frame < 0 {
+		return charState{}, false
+	}
+	past := rb.states[frame%int64(len(rb.states))]
+	if past.frame != frame || past.chars == nil {
+		return charState{}, false
+	}
+	state, ok := past.chars[clientId]
+	return state, ok
+}
+
+// rewind returns the state of the victim's character as the attacker saw it, according to the attacker's lag,
+// false if there is no lag or the past state is unknown
+func (gw *gameWorld) rewind(attacker, victim *player) (charState, bool) {
+	if attacker.lag == 0 {
+		return charState{}, false
+	}
+	return gw.past.at(gw.frame-int64(attacker.lag), victim.clientId)
+}
+
+// lagOf returns the number of frames the player is behind the server, based on the frame
+// the control notification was sent at, capped at the MaxRewind of the rules
+func (gw *gameWorld) lagOf(frame int64) int {
+	if frame <= 0 || frame >= gw.frame {
+		return 0
+	}
+	if lag := gw.frame - frame; lag < int64(gw.rules.MaxRewind) {
+		return int(lag)
+	}
+	return gw.rules.MaxRewind
+}
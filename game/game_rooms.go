@@ -0,0 +1,553 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// DefaultRoom is the room clients join if they do not name one, it is never destroyed
+const DefaultRoom = "main"
+
+// emptyRoomTimeout is how long a room nobody joined is kept open, the empty rooms are looked for this often as well
+const emptyRoomTimeout = time.Minute
+
+// recentMatchCount is the number of match records listed by the matches request
+const recentMatchCount = 20
+
+// room is a running game with the means to stop it and to pass controls to it
+type room struct {
+	game      *GameController
+	ctx       context.Context
+	cancel    context.CancelFunc
+	controlCh chan *model.ControlNotify
+	created   time.Time
+}
+
+// RoomManager creates, runs and destroys game rooms, and routes the clients' requests,
+// controls and acknowledgements to the room they joined
+type RoomManager struct {
+	mu        sync.RWMutex
+	ctx       context.Context
+	initOnce  sync.Once
+	step      time.Duration
+	controlCh chan *model.ControlNotify
+	rooms     map[string]*room
+	// clients maps the Client IDs to the name of the room they joined
+	clients map[string]string
+	// playbacks are the replays played back to the clients by Client ID
+	playbacks map[string]*playback
+	// matchMu serializes the saving of the finished rounds
+	matchMu      sync.Mutex
+	sendFn       func(clientId string, msg *model.ServerMsg)
+	connStatusFn func(clientId string, status model.ConnStatus)
+}
+
+// NewRoomManager creates a RoomManager in the given context, its games are updated once every step,
+// and the controls of every client are received on the control channel
+func NewRoomManager(ctx context.Context, step time.Duration, controlCh chan *model.ControlNotify) *RoomManager {
+	return &RoomManager{
+		ctx:          ctx,
+		step:         step,
+		controlCh:    controlCh,
+		rooms:        make(map[string]*room),
+		clients:      make(map[string]string),
+		playbacks:    make(map[string]*playback),
+		sendFn:       func(clientId string, msg *model.ServerMsg) {},
+		connStatusFn: func(clientId string, status model.ConnStatus) {},
+	}
+}
+
+//////////////////////
+/// Public Methods //
+////////////////////
+
+func (rm *RoomManager) SetSendFn(f func(clientId string, msg *model.ServerMsg)) {
+	rm.sendFn = f
+}
+
+func (rm *RoomManager) SetConnStatusFn(f func(clientId string, status model.ConnStatus)) {
+	rm.connStatusFn = f
+}
+
+// Start creates the default room and starts routing the controls
+func (rm *RoomManager) Start() {
+	rm.initOnce.Do(func() {
+		rm.mu.Lock()
+		rules := utils.Conf(rm.ctx).WorldRules
+		if _, err := rm.createRoom(DefaultRoom, rules); err != nil {
+			log.Errorf("Failed to start the default room on its level, using the default map %s", err.Error())
+			rules.Level = ""
+			rm.createRoom(DefaultRoom, rules)
+		}
+		rm.mu.Unlock()
+		go rm.run()
+	})
+}
+
+// Request handles the room related client requests, and passes the rest to the client's room
+func (rm *RoomManager) Request(req *model.ClientRequest) {
+	switch req.RequestType {
+	case "rooms":
+		req.Response(model.ResponseStatusOK, rm.list())
+	case "matches":
+		rm.handleMatches(req)
+	case "create_room":
+		rm.handleCreateRoom(req)
+	case "login":
+		rm.handleLogin(req)
+	case "spectate":
+		rm.handleSpectate(req)
+	case "replay":
+		rm.handleReplay(req)
+	case "playback":
+		rm.handlePlayback(req)
+	default:
+		game := rm.clientGame(req.ClientId)
+		if game == nil {
+			req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Not in a room, cannot handle request type %s", req.RequestType))
+			return
+		}
+		game.Request(req)
+	}
+}
+
+// Logout stops the replay played back to the client, removes the client from its room,
+// and destroys the room if it became empty
+func (rm *RoomManager) Logout(clientId string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.stopPlayback(clientId)
+
+	name, ok := rm.clients[clientId]
+	if !ok {
+		return
+	}
+	delete(rm.clients, clientId)
+
+	r := rm.rooms[name]
+	r.game.Logout(clientId)
+	if name != DefaultRoom && r.game.Empty() {
+		rm.destroyRoom(name)
+	}
+}
+
+// Ack passes the acknowledged frame of the client to its room, or to the replay played back to it
+func (rm *RoomManager) Ack(clientId string, frame int64) {
+	if pb := rm.clientPlayback(clientId); pb != nil {
+		pb.ack(frame)
+		return
+	}
+	if game := rm.clientGame(clientId); game != nil {
+		game.Ack(clientId, frame)
+	}
+}
+
+///////////////////////
+/// Private Methods //
+/////////////////////
+
+// run passes the incoming controls to the room of the sender, and destroys the rooms nobody joined
+func (rm *RoomManager) run() {
+	reap := time.NewTicker(emptyRoomTimeout)
+	defer reap.Stop()
+
+	for {
+		select {
+		case <-rm.ctx.Done():
+			log.Warnf("Room Manager's context is done, returning...")
+			return
+
+		case now := <-reap.C:
+			rm.mu.Lock()
+			rm.reapRooms(now)
+			rm.mu.Unlock()
+
+		case ctl := <-rm.controlCh:
+			rm.mu.RLock()
+			r, ok := rm.rooms[rm.clients[ctl.ClientId]]
+			rm.mu.RUnlock()
+			if !ok {
+				log.Debugf("Control notification from client %s who is not in a room", ctl.ClientId)
+				continue
+			}
+			// The room may be destroyed meanwhile, then nobody receives the control
+			select {
+			case r.controlCh <- ctl:
+			case <-r.ctx.Done():
+				log.Debugf("Control notification from client %s is dropped, room %s is destroyed", ctl.ClientId, r.game.name)
+			}
+		}
+	}
+}
+
+// createRoom creates and starts a new room on the level of the rules, the caller must hold the lock.
+// The rules are validated again, as the requests may override some of them
+func (rm *RoomManager) createRoom(name string, rules model.WorldRules) (*room, error) {
+	if err := rules.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "Cannot create room %s with invalid rules", name)
+	}
+	worldMap := model.DefaultWorldMap()
+	if rules.Level != "" {
+		level, err := loadLevel(utils.DB(rm.ctx), rules.Level)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot create room %s", name)
+		}
+		worldMap = level.WorldMap()
+		rules.BlockSize = level.TileSize
+	}
+
+	ctx, cancel := context.WithCancel(rm.ctx)
+	r := &room{
+		ctx:       ctx,
+		cancel:    cancel,
+		controlCh: make(chan *model.ControlNotify),
+		created:   time.Now(),
+	}
+	r.game = NewGameController(ctx, name, rules, worldMap, rm.step, r.controlCh)
+	r.game.SetSendFn(rm.sendFn)
+	r.game.SetConnStatusFn(rm.connStatusFn)
+	r.game.SetReplayFn(rm.saveMatch)
+	r.game.SetPlaylist(rm.loadPlaylist(rules.Playlist))
+	r.game.Start()
+
+	rm.rooms[name] = r
+	if rules.Level != "" {
+		log.Infof("Room %s created on level %s", name, rules.Level)
+	} else {
+		log.Infof("Room %s created on the default map", name)
+	}
+	return r, nil
+}
+
+// loadPlaylist loads the levels of the playlist, the missing and invalid levels are left out
+func (rm *RoomManager) loadPlaylist(names []string) (levels []model.Level) {
+	for _, name := range names {
+		level, err := loadLevel(utils.DB(rm.ctx), name)
+		if err != nil {
+			log.Errorf("Level %s is left out of the playlist %s", name, err.Error())
+			continue
+		}
+		levels = append(levels, level)
+	}
+	return
+}
+
+// saveMatch stores the record and the replay of a finished round in the matches bucket,
+// the matches of the rooms are saved one by one, so the stats of the players are updated consistently
+func (rm *RoomManager) saveMatch(replay model.Replay) {
+	rm.matchMu.Lock()
+	defer rm.matchMu.Unlock()
+
+	id, err := saveMatch(utils.DB(rm.ctx), replay, utils.Conf(rm.ctx).MaxReplays)
+	if err != nil {
+		log.Errorf("Failed to save round %d in room %s %s", replay.Round, replay.Room, err.Error())
+		return
+	}
+	if id != "" {
+		log.Infof("Round %d in room %s is saved as match %s", replay.Round, replay.Room, id)
+	}
+}
+
+// destroyRoom stops and removes the room, the caller must hold the lock
+func (rm *RoomManager) destroyRoom(name string) {
+	rm.rooms[name].cancel()
+	delete(rm.rooms, name)
+	log.Infof("Room %s destroyed", name)
+}
+
+// reapRooms destroys the empty rooms, except the default one, which are older than the emptyRoomTimeout.
+// The rooms are destroyed when their last client leaves, this catches the rooms nobody joined.
+// The caller must hold the lock
+func (rm *RoomManager) reapRooms(now time.Time) {
+	for name, r := range rm.rooms {
+		if name != DefaultRoom && now.Sub(r.created) >= emptyRoomTimeout && r.game.Empty() {
+			rm.destroyRoom(name)
+		}
+	}
+}
+
+// full checks if the number of rooms reached the limit of the config, the caller must hold the lock
+func (rm *RoomManager) full() bool {
+	return len(rm.rooms) >= utils.Conf(rm.ctx).MaxRooms
+}
+
+// clientGame returns the game of the room the client joined, nil if the client is not in a room
+func (rm *RoomManager) clientGame(clientId string) *GameController {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if r, ok := rm.rooms[rm.clients[clientId]]; ok {
+		return r.game
+	}
+	return nil
+}
+
+// clientPlayback returns the replay played back to the client, nil if the client is not watching a replay
+func (rm *RoomManager) clientPlayback(clientId string) *playback {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return rm.playbacks[clientId]
+}
+
+// stopPlayback stops the replay played back to the client if there is any, the caller must hold the lock
+func (rm *RoomManager) stopPlayback(clientId string) {
+	if pb, ok := rm.playbacks[clientId]; ok {
+		pb.stop()
+		delete(rm.playbacks, clientId)
+	}
+}
+
+// list returns the information of every room ordered by name
+func (rm *RoomManager) list() []model.RoomInfo {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	rooms := []model.RoomInfo{}
+	for _, r := range rm.rooms {
+		rooms = append(rooms, r.game.Info())
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].Name < rooms[j].Name })
+	return rooms
+}
+
+func (rm *RoomManager) handleCreateRoom(req *model.ClientRequest) {
+	var createRequest model.CreateRoomRequest
+	if err := json.Unmarshal([]byte(req.RequestBody), &createRequest); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid CreateRoomRequest JSON %s", err.Error()))
+		return
+	}
+	if err := createRequest.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid CreateRoomRequest %s", err.Error()))
+		return
+	}
+
+	// The room is played by the server's rules, its creator can only choose a few of them
+	rules := utils.Conf(rm.ctx).WorldRules
+	if createRequest.Level != "" {
+		rules.Level = createRequest.Level
+	}
+	if createRequest.GameMode != "" {
+		rules.GameMode = createRequest.GameMode
+	}
+	if createRequest.Teams != nil {
+		rules.Teams = *createRequest.Teams
+	}
+	if createRequest.Lives != nil {
+		rules.Lives = *createRequest.Lives
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if _, ok := rm.rooms[createRequest.Name]; ok {
+		req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Room %s already exists", createRequest.Name))
+		return
+	}
+	if rm.full() {
+		req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Cannot create room %s, too many rooms are open", createRequest.Name))
+		return
+	}
+	r, err := rm.createRoom(createRequest.Name, rules)
+	if err != nil {
+		req.Response(model.ResponseStatusBadRequest, err.Error())
+		return
+	}
+	req.Response(model.ResponseStatusOK, r.game.Info())
+}
+
+// handleLogin logs the client in to the requested room, the room is created if it does not exist
+func (rm *RoomManager) handleLogin(req *model.ClientRequest) {
+	var loginRequest model.LoginRequest
+	if err := json.Unmarshal([]byte(req.RequestBody), &loginRequest); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid LoginRequest JSON %s", err.Error()))
+		return
+	}
+	if err := loginRequest.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid LoginRequest %s", err.Error()))
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	// Joining a room ends the replay the client is watching
+	rm.stopPlayback(req.ClientId)
+
+	// Spectators can start playing in the room they are watching
+	if name, ok := rm.clients[req.ClientId]; ok {
+		if !rm.rooms[name].game.Spectating(req.ClientId) || (loginRequest.Room != "" && loginRequest.Room != name) {
+			req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Already in room %s", name))
+			return
+		}
+		loginRequest.Room = name
+	}
+	if loginRequest.Room == "" {
+		loginRequest.Room = DefaultRoom
+	}
+
+	r, ok := rm.rooms[loginRequest.Room]
+	if !ok {
+		if rm.full() {
+			req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Cannot create room %s, too many rooms are open", loginRequest.Room))
+			return
+		}
+		rules := utils.Conf(rm.ctx).WorldRules
+		if loginRequest.Level != "" {
+			rules.Level = loginRequest.Level
+		}
+		if loginRequest.GameMode != "" {
+			rules.GameMode = loginRequest.GameMode
+		}
+		var err error
+		if r, err = rm.createRoom(loginRequest.Room, rules); err != nil {
+			req.Response(model.ResponseStatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if r.game.Login(req) {
+		rm.clients[req.ClientId] = loginRequest.Room
+	} else if loginRequest.Room != DefaultRoom && r.game.Empty() {
+		rm.destroyRoom(loginRequest.Room)
+	}
+}
+
+// handleSpectate lets the client watch the requested room without playing, or stop playing in its room
+func (rm *RoomManager) handleSpectate(req *model.ClientRequest) {
+	var spectateRequest model.SpectateRequest
+	if err := json.Unmarshal([]byte(req.RequestBody), &spectateRequest); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid SpectateRequest JSON %s", err.Error()))
+		return
+	}
+	if err := spectateRequest.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid SpectateRequest %s", err.Error()))
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	// Players can start watching the room they are playing in
+	if name, ok := rm.clients[req.ClientId]; ok {
+		if spectateRequest.Room != "" && spectateRequest.Room != name {
+			req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Already in room %s", name))
+			return
+		}
+		spectateRequest.Room = name
+	}
+	if spectateRequest.Room == "" {
+		spectateRequest.Room = DefaultRoom
+	}
+
+	r, ok := rm.rooms[spectateRequest.Room]
+	if !ok {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Room %s does not exist", spectateRequest.Room))
+		return
+	}
+
+	// Watching a room ends the replay the client is watching
+	rm.stopPlayback(req.ClientId)
+
+	if r.game.Spectate(req) {
+		rm.clients[req.ClientId] = spectateRequest.Room
+	}
+}
+
+// handleReplay plays the replay of the requested match back to the client, it replaces the replay
+// the client is watching, but the clients in a room cannot watch replays
+func (rm *RoomManager) handleReplay(req *model.ClientRequest) {
+	var replayRequest model.ReplayRequest
+	if err := json.Unmarshal([]byte(req.RequestBody), &replayRequest); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid ReplayRequest JSON %s", err.Error()))
+		return
+	}
+	if err := replayRequest.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid ReplayRequest %s", err.Error()))
+		return
+	}
+	if replayRequest.Speed == 0 {
+		replayRequest.Speed = 1
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if name, ok := rm.clients[req.ClientId]; ok {
+		req.Response(model.ResponseStatusNotAccaptable, fmt.Sprintf("Already in room %s", name))
+		return
+	}
+
+	replay, err := loadReplay(utils.DB(rm.ctx), replayRequest.Match)
+	if err != nil {
+		req.Response(model.ResponseStatusBadRequest, err.Error())
+		return
+	}
+
+	rm.stopPlayback(req.ClientId)
+	pb := newPlayback(rm.ctx, req.ClientId, replay, replayRequest.Speed, rm.step, rm.sendFn)
+	rm.playbacks[req.ClientId] = pb
+
+	// Change the associated wsConn's status to Authenticated
+	rm.connStatusFn(req.ClientId, model.Status_Authenticated)
+
+	// Send the accepted status and the world dump at the start of the replay
+	req.Response(model.ResponseStatusAccepted, pb.dump())
+	pb.start()
+	log.Debugf("Client %s is watching match %s", req.ClientId, replay.Id)
+}
+
+// handlePlayback pauses, resumes, seeks, speeds up or slows down, or stops the replay the client is watching
+func (rm *RoomManager) handlePlayback(req *model.ClientRequest) {
+	var ctl model.PlaybackControl
+	if err := json.Unmarshal([]byte(req.RequestBody), &ctl); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid PlaybackControl JSON %s", err.Error()))
+		return
+	}
+	if err := ctl.Validate(); err != nil {
+		req.Response(model.ResponseStatusBadRequest, fmt.Sprintf("Invalid PlaybackControl %s", err.Error()))
+		return
+	}
+
+	if ctl.Action == model.Playback_Stop {
+		rm.mu.Lock()
+		_, ok := rm.playbacks[req.ClientId]
+		rm.stopPlayback(req.ClientId)
+		rm.mu.Unlock()
+		if !ok {
+			req.Response(model.ResponseStatusBadRequest, "Not watching a replay")
+			return
+		}
+		req.Response(model.ResponseStatusOK, "Stopped")
+		return
+	}
+
+	// Seeking may simulate the whole replay, it is done without holding up the other clients
+	pb := rm.clientPlayback(req.ClientId)
+	if pb == nil {
+		req.Response(model.ResponseStatusBadRequest, "Not watching a replay")
+		return
+	}
+	req.Response(model.ResponseStatusOK, pb.control(ctl))
+}
+
+// handleMatches responds with the records of the last matches, their IDs can be used to watch their replays
+func (rm *RoomManager) handleMatches(req *model.ClientRequest) {
+	records, err := recentMatches(utils.DB(rm.ctx), recentMatchCount)
+	if err != nil {
+		req.Response(model.ResponseStatusServerError, err.Error())
+		return
+	}
+	req.Response(model.ResponseStatusOK, records)
+}
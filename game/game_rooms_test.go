@@ -0,0 +1,235 @@
+package game
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/database"
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_RouteControl(t *testing.T) {
+	req := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	controlCh := make(chan *model.ControlNotify)
+	rm := NewRoomManager(ctx, time.Millisecond, controlCh)
+
+	rm.mu.Lock()
+	r, err := rm.createRoom("test", model.DefaultConf().WorldRules)
+	req.NoError(err)
+	rm.clients["a"] = "test"
+	rm.mu.Unlock()
+	go rm.run()
+
+	// The room is stopped as if it was destroyed right after the control is routed to it
+	r.cancel()
+	for i := 0; i < 3; i++ {
+		select {
+		case controlCh <- &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Left}:
+		case <-time.After(time.Second):
+			req.Fail("The controls should not be blocked by a stopped room")
+		}
+	}
+}
+
+func Test_ReapRooms(t *testing.T) {
+	req := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rm := NewRoomManager(ctx, time.Millisecond, make(chan *model.ControlNotify))
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	for _, name := range []string{DefaultRoom, "old", "new"} {
+		_, err := rm.createRoom(name, model.DefaultConf().WorldRules)
+		req.NoError(err)
+	}
+	now := time.Now()
+	rm.rooms[DefaultRoom].created = now.Add(-2 * emptyRoomTimeout)
+	rm.rooms["old"].created = now.Add(-2 * emptyRoomTimeout)
+
+	rm.reapRooms(now)
+	_, ok := rm.rooms[DefaultRoom]
+	req.True(ok, "The default room should never be destroyed")
+	_, ok = rm.rooms["new"]
+	req.True(ok, "The new rooms should be kept for the clients to join")
+	_, ok = rm.rooms["old"]
+	req.False(ok, "The old empty rooms should be destroyed")
+}
+
+func Test_MaxRooms(t *testing.T) {
+	req := require.New(t)
+
+	conf := model.DefaultConf()
+	conf.MaxRooms = 2
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "config", conf))
+	defer cancel()
+	rm := NewRoomManager(ctx, time.Millisecond, make(chan *model.ControlNotify))
+
+	var status model.ServerResponseStatus
+	createRoom := func(name string) {
+		rm.Request(&model.ClientRequest{
+			ClientId:    "a",
+			RequestType: "create_room",
+			RequestBody: `{"name": "` + name + `"}`,
+			Response:    func(s model.ServerResponseStatus, payload interface{}) { status = s },
+		})
+	}
+
+	createRoom("first")
+	req.Equal(model.ResponseStatusOK, status, "A room should be created under the limit")
+	createRoom("second")
+	req.Equal(model.ResponseStatusOK, status, "A room should be created under the limit")
+	createRoom("third")
+	req.Equal(model.ResponseStatusNotAccaptable, status, "No more rooms should be created over the limit")
+	req.Len(rm.rooms, 2)
+}
+
+func Test_CreateRoomRules(t *testing.T) {
+	req := require.New(t)
+
+	conf := model.DefaultConf()
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "config", conf))
+	defer cancel()
+	rm := NewRoomManager(ctx, time.Millisecond, make(chan *model.ControlNotify))
+
+	var status model.ServerResponseStatus
+	createRoom := func(body string) {
+		rm.Request(&model.ClientRequest{
+			ClientId:    "a",
+			RequestType: "create_room",
+			RequestBody: body,
+			Response:    func(s model.ServerResponseStatus, payload interface{}) { status = s },
+		})
+	}
+
+	createRoom(`{"name": "custom", "game_mode": "last_rabbit", "teams": 2, "lives": 5, "world_rules": {"gravity": 5, "rewind_window": 1099511627776}}`)
+	req.Equal(model.ResponseStatusOK, status, "The room should be created")
+	want := conf.WorldRules
+	want.GameMode, want.Teams, want.Lives = model.Mode_LastRabbit, 2, 5
+	req.Equal(want, rm.rooms["custom"].game.world.rules, "Only the allowed rules should be chosen by the creator of the room")
+
+	createRoom(`{"name": "crowded", "teams": 9}`)
+	req.Equal(model.ResponseStatusBadRequest, status, "The number of teams should be limited")
+	createRoom(`{"name": "immortal", "lives": 1000}`)
+	req.Equal(model.ResponseStatusBadRequest, status, "The number of lives should be limited")
+}
+
+func Test_CreateRoomValidation(t *testing.T) {
+	req := require.New(t)
+
+	conf := model.DefaultConf()
+	conf.WorldRules.Lives = 0
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "config", conf))
+	defer cancel()
+	rm := NewRoomManager(ctx, time.Millisecond, make(chan *model.ControlNotify))
+
+	var status model.ServerResponseStatus
+	request := func(requestType, body string) {
+		rm.Request(&model.ClientRequest{
+			ClientId:    "a",
+			RequestType: requestType,
+			RequestBody: body,
+			Response:    func(s model.ServerResponseStatus, payload interface{}) { status = s },
+		})
+	}
+
+	request("create_room", `{"name": "rabbits", "game_mode": "last_rabbit"}`)
+	req.Equal(model.ResponseStatusBadRequest, status, "A last rabbit standing room should not be created without lives")
+	request("login", `{"name": "Alice", "color": "red", "room": "rabbits", "game_mode": "last_rabbit"}`)
+	req.Equal(model.ResponseStatusBadRequest, status, "A last rabbit standing room should not be created by a login without lives")
+	req.Len(rm.rooms, 0, "No room should be created with invalid rules")
+
+	request("create_room", `{"name": "rabbits", "game_mode": "last_rabbit", "lives": 2}`)
+	req.Equal(model.ResponseStatusOK, status, "A last rabbit standing room should be created with lives")
+}
+
+func Test_Spectate(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "bnj")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	db := database.New()
+	req.NoError(db.Init(model.GetDBInitConfig(&model.DataBase{Type: "bolt", URL: filepath.Join(dir, "bnj.db")})))
+	defer db.Close()
+
+	conf := model.DefaultConf()
+	ctx, cancel := context.WithCancel(context.WithValue(context.WithValue(context.Background(), "config", conf), "database", db))
+	defer cancel()
+	// The game is only updated by the test
+	rm := NewRoomManager(ctx, time.Hour, make(chan *model.ControlNotify))
+	updates := make(chan string, 1000)
+	rm.SetSendFn(func(clientId string, msg *model.ServerMsg) {
+		if msg.MsgType == model.ServerMsg_Update {
+			updates <- clientId
+		}
+	})
+	rm.mu.Lock()
+	r, err := rm.createRoom(DefaultRoom, conf.WorldRules)
+	rm.mu.Unlock()
+	req.NoError(err)
+
+	var status model.ServerResponseStatus
+	request := func(clientId, requestType, body string) {
+		rm.Request(&model.ClientRequest{
+			ClientId:    clientId,
+			RequestType: requestType,
+			RequestBody: body,
+			Response:    func(s model.ServerResponseStatus, payload interface{}) { status = s },
+		})
+	}
+	update := func() {
+		for i := 0; i < 3; i++ {
+			r.game.update()
+		}
+	}
+
+	request("s", "spectate", `{}`)
+	req.Equal(model.ResponseStatusAccepted, status, "The client should watch the default room")
+	req.True(r.game.Spectating("s"))
+	req.Equal(1, r.game.Info().Spectators)
+	update()
+	req.Len(r.game.world.players, 0, "The spectator should not take a player slot, nor bring bots")
+	req.Equal(model.Phase_Waiting, r.game.world.round.phase, "The spectator should not count toward MinPlayer")
+	select {
+	case clientId := <-updates:
+		req.Equal("s", clientId, "The spectator should receive the world updates")
+	case <-time.After(time.Second):
+		req.Fail("The spectator should receive the world updates")
+	}
+	request("s", "spectate", `{}`)
+	req.Equal(model.ResponseStatusNotAccaptable, status, "The spectator should not watch twice")
+
+	request("a", "login", `{"name": "Alice", "color": "red"}`)
+	req.Equal(model.ResponseStatusAccepted, status)
+	update()
+	req.Len(r.game.world.players, 2, "A bot should join the only human, the spectator is not counted")
+	req.Equal(1, r.game.world.humans())
+
+	request("s", "login", `{"name": "Sam", "color": "blue"}`)
+	req.Equal(model.ResponseStatusAccepted, status, "The spectator should start playing")
+	req.False(r.game.Spectating("s"))
+	update()
+	req.Len(r.game.world.players, 2, "The bot should leave as the spectator starts playing")
+	req.Equal(2, r.game.world.humans())
+
+	request("s", "spectate", `{"room": "other"}`)
+	req.Equal(model.ResponseStatusNotAccaptable, status, "The player should not watch another room")
+	request("s", "spectate", `{}`)
+	req.Equal(model.ResponseStatusAccepted, status, "The player should start watching its room")
+	req.True(r.game.Spectating("s"))
+	update()
+	req.Len(r.game.world.players, 2, "The bot should join again as the player starts watching")
+	req.Equal(1, r.game.world.humans())
+	req.Equal(1, r.game.Info().Spectators)
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+)
+
+// setPlaylist sets the levels the world rotates through, the rotation continues after the current level
+func (gw *gameWorld) setPlaylist(levels []model.Level) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	gw.playlist = levels
+	gw.playing = -1
+	for i, level := range levels {
+		if level.Name == gw.rules.Level {
+			gw.playing = i
+		}
+	}
+}
+
+// rotateMap changes the map to the next level of the playlist, in order or at random according to the rules
+func (gw *gameWorld) rotateMap() {
+	if len(gw.playlist) == 0 {
+		return
+	}
+	next := gw.playing
+	switch gw.rules.Rotation {
+	case model.Rotation_Playlist:
+		next = (gw.playing + 1) % len(gw.playlist)
+	case model.Rotation_Random:
+		// Pick a different level than the current one, if there is any
+		if next = gw.rng.Intn(len(gw.playlist)); next == gw.playing && len(gw.playlist) > 1 {
+			next = (next + 1) % len(gw.playlist)
+		}
+	}
+	if next == gw.playing || next < 0 {
+		return
+	}
+	gw.playing = next
+	gw.changeMap(gw.playlist[next])
+}
+
+// changeMap replaces the map of the world with the level's, clears the items and particles,
+// respawns every character on the new map, and sends the new map to the clients
+func (gw *gameWorld) changeMap(level model.Level) {
+	log.Infof("Changing the map to level %s", level.Name)
+
+	gw.rules.Level = level.Name
+	gw.rules.BlockSize = level.TileSize
+	gw.worldMap = level.WorldMap()
+	gw.rect = newRect(0, 0, len(gw.worldMap.Rows[0])*gw.rules.BlockSize, len(gw.worldMap.Rows)*gw.rules.BlockSize)
+	// The past states are on the previous map
+	gw.past = newRewindBuffer(gw.rules.RewindWindow)
+
+	gw.objects = nil
+	for _, player := range gw.players {
+		if player.char != nil {
+			player.char.size = gw.rules.BlockSize
+			gw.respawn(player)
+			gw.objects = append(gw.objects, player.char)
+		}
+	}
+
+	gw.outbox = append(gw.outbox, model.NewMapMsg(&model.MapUpdate{
+		Level:     level.Name,
+		BlockSize: gw.rules.BlockSize,
+		WorldMap:  gw.worldMap,
+	}))
+}
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_RotateMap(t *testing.T) {
+	req := require.New(t)
+
+	levels := []model.Level{
+		{
+			Name:       "small",
+			Background: "black",
+			Rows:       []string{"1111", "1001", "1001", "1111"},
+			TileSize:   16,
+		},
+		{
+			Name:       "large",
+			Background: "white",
+			Rows:       []string{"111111", "100001", "100001", "100001", "111111"},
+			TileSize:   20,
+			Spawns:     []model.Spawn{{Col: 2, Row: 2}},
+		},
+	}
+
+	gw := testWorld(levels[0].Rows...)
+	gw.rules.Level = "small"
+	gw.rules.Rotation = model.Rotation_Playlist
+	gw.setPlaylist(levels)
+	p := newPlayer("a", "Alice", "red", "")
+	gw.addPlayer(p)
+
+	for _, want := range []model.Level{levels[1], levels[0], levels[1]} {
+		gw.round.phase = model.Phase_RoundOver
+		gw.round.timer = 1
+		gw.updateRound()
+
+		req.Equal(want.Rows, gw.worldMap.Rows, "The map should be changed to the next level of the playlist")
+		req.Equal(want.TileSize, p.char.size, "The characters should be resized to the tile size of the level")
+		req.True(gw.isEmpty(p.char.x, p.char.y, p.char.size), "The characters should respawn on an empty place")
+
+		var maps []*model.MapUpdate
+		for _, msg := range gw.flush() {
+			if msg.MsgType == model.ServerMsg_Map {
+				maps = append(maps, msg.Map)
+			}
+		}
+		req.Len(maps, 1, "The new map should be sent to the clients")
+		req.Equal(want.Name, maps[0].Level, "The map message should name the new level")
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"math/rand"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+)
+
+// countdownTime is the number of seconds between the last player's arrival and the start of the round
+const countdownTime = 3
+
+// round holds the state of the current round
+type round struct {
+	number int
+	phase  model.RoundPhase
+	// timer is the number of frames left from the countdown or the intermission
+	timer  int
+	winner string
+	// winnerTeam is the team of the winner in team mode
+	winnerTeam string
+}
+
+// updateRound advances the round's state machine by one frame
+func (gw *gameWorld) updateRound() {
+	enough := len(gw.players) >= gw.minPlayer()
+
+	switch gw.round.phase {
+	case model.Phase_Waiting:
+		if enough {
+			gw.changePhase(model.Phase_Countdown, countdownTime)
+		}
+
+	case model.Phase_Countdown:
+		if !enough {
+			gw.changePhase(model.Phase_Waiting, 0)
+			return
+		}
+		if gw.round.timer--; gw.round.timer <= 0 {
+			gw.startRound(gw.rng.Int63())
+		}
+
+	case model.Phase_Playing:
+		if !enough {
+			log.Infof("Round %d is aborted, not enough players", gw.round.number)
+			gw.stopRecording()
+			gw.revive()
+			gw.changePhase(model.Phase_Waiting, 0)
+			return
+		}
+		gw.mode.Update(gw)
+		if winner := gw.mode.Winner(gw); winner != nil {
+			gw.endRound(winner)
+		}
+
+	case model.Phase_RoundOver:
+		if gw.round.timer--; gw.round.timer <= 0 {
+			gw.resetScores()
+			gw.revive()
+			gw.rotateMap()
+			gw.changePhase(model.Phase_Waiting, 0)
+		}
+	}
+}
+
+// startRound respawns every character and starts a new round with the given random seed,
+// the items and particles of the previous round are cleared, so the round can be recorded from a clean state
+func (gw *gameWorld) startRound(seed int64) {
+	recording := gw.newRecording(seed)
+	gw.seed = seed
+	gw.rng = rand.New(rand.NewSource(seed))
+
+	gw.round.number++
+	gw.round.winner = ""
+	gw.round.winnerTeam = ""
+	gw.resetScores()
+	gw.revive()
+	gw.objects = nil
+	for _, player := range gw.players {
+		// The player may not have found a place to spawn on
+		if player.char == nil {
+			continue
+		}
+		gw.respawn(player)
+		gw.objects = append(gw.objects, player.char)
+	}
+	// The past states are from the previous round
+	gw.past = newRewindBuffer(gw.rules.RewindWindow)
+	gw.participants = append([]*player(nil), gw.players...)
+	gw.mode.StartRound(gw)
+	log.Infof("Round %d of %s started with %d players", gw.round.number, gw.mode.Name(), len(gw.players))
+	gw.changePhase(model.Phase_Playing, 0)
+	gw.recording = recording
+}
+
+// endRound declares the winner of the round and starts the intermission
+func (gw *gameWorld) endRound(winner *player) {
+	winner.roundWins++
+	gw.round.winner = winner.name
+	gw.round.winnerTeam = winner.team
+	log.Infof("Round %d is won by %s", gw.round.number, winner.name)
+	gw.stopRecording()
+	gw.changePhase(model.Phase_RoundOver, gw.rules.WaitTime)
+}
+
+func (gw *gameWorld) resetScores() {
+	for _, player := range gw.players {
+		player.roundScore = 0
+		player.stomps, player.deaths = 0, 0
+	}
+}
+
+// changePhase sets the round's phase and its timer (in seconds), and sends the change to the clients
+func (gw *gameWorld) changePhase(phase model.RoundPhase, seconds int) {
+	log.Debugf("Round %d phase changed from %s to %s", gw.round.number, gw.round.phase, phase)
+	gw.round.phase = phase
+	gw.round.timer = seconds * FrameRate
+	roundUpdate := gw.roundDump()
+	gw.outbox = append(gw.outbox, model.NewRoundMsg(&roundUpdate))
+}
+
+// minPlayer is the number of players needed to start a round, at least one
+func (gw *gameWorld) minPlayer() int {
+	if gw.rules.MinPlayer < 1 {
+		return 1
+	}
+	return gw.rules.MinPlayer
+}
+
+func (gw *gameWorld) roundDump() model.RoundUpdate {
+	return model.RoundUpdate{
+		Round:      gw.round.number,
+		Phase:      gw.round.phase,
+		Duration:   gw.round.timer / FrameRate,
+		Winner:     gw.round.winner,
+		WinnerTeam: gw.round.winnerTeam,
+		Players:    gw.playerDumps(),
+		Teams:      gw.teamScores(),
+	}
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_RoundLifecycle(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.MinPlayer = 2
+	rules.TargetScore = 2
+	rules.WaitTime = 1
+	rules.BotLevel = model.BotLevel_None
+	gw := newGameWorld(rules, model.DefaultWorldMap(), 1)
+	step := func(frames int) {
+		for i := 0; i < frames; i++ {
+			gw.updateRound()
+		}
+	}
+	alice, bob := newPlayer("a", "Alice", "red", ""), newPlayer("b", "Bob", "blue", "")
+
+	gw.addPlayer(alice)
+	step(FrameRate)
+	req.Equal(model.Phase_Waiting, gw.round.phase, "The round should wait for enough players")
+
+	gw.addPlayer(bob)
+	step(1)
+	req.Equal(model.Phase_Countdown, gw.round.phase, "The countdown should start with enough players")
+	gw.removePlayer("b")
+	step(1)
+	req.Equal(model.Phase_Waiting, gw.round.phase, "The countdown should stop when a player leaves")
+
+	gw.addPlayer(bob)
+	step(1)
+	step(countdownTime*FrameRate - 1)
+	req.Equal(model.Phase_Countdown, gw.round.phase, "The round should not start before the end of the countdown")
+	step(1)
+	req.Equal(model.Phase_Playing, gw.round.phase, "The round should start at the end of the countdown")
+	req.Equal(1, gw.round.number)
+
+	gw.squash(alice, bob)
+	step(1)
+	req.Equal(model.Phase_Playing, gw.round.phase, "The round should go on below the target score")
+	gw.squash(alice, bob)
+	step(1)
+	req.Equal(model.Phase_RoundOver, gw.round.phase, "The round should be over at the target score")
+	req.Equal("Alice", gw.round.winner)
+	req.Equal(1, alice.roundWins, "The winner should get a round win")
+	req.Equal(0, bob.roundWins)
+	req.Len(gw.replays, 1, "The won round should be recorded")
+
+	step(rules.WaitTime*FrameRate - 1)
+	req.Equal(model.Phase_RoundOver, gw.round.phase, "The intermission should last for WaitTime")
+	req.Equal(2, alice.roundScore, "The scores should be kept during the intermission")
+	step(1)
+	req.Equal(model.Phase_Waiting, gw.round.phase, "The round should wait again after the intermission")
+	req.Equal(0, alice.roundScore, "The scores should be reset after the intermission")
+	req.Equal(1, alice.roundWins, "The round wins should be kept")
+
+	step(1 + countdownTime*FrameRate)
+	req.Equal(model.Phase_Playing, gw.round.phase, "The next round should start")
+	req.Equal(2, gw.round.number)
+	gw.removePlayer("b")
+	step(1)
+	req.Equal(model.Phase_Waiting, gw.round.phase, "The round should be aborted when a player leaves")
+	req.Len(gw.replays, 2, "The aborted round should be recorded")
+	req.Equal("", gw.replays[1].Winner, "The aborted round should have no winner")
+}
@@ -0,0 +1,134 @@
+package game
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/donbattery/bnj/model"
+)
+
+// Simulation is a headless game world, without any connection, ticker or goroutine.
+// It is stepped synchronously frame by frame, with scripted control inputs,
+// which makes it suitable for gameplay tests and tooling
+type Simulation struct {
+	world *gameWorld
+	// actions are the scripted changes of the world (controls, joins and leaves) by the frame they are applied before
+	actions map[int64][]func()
+	// messages collected from the world during the steps
+	messages []*model.ServerMsg
+	// replays are the recordings of the rounds finished during the steps
+	replays []model.Replay
+}
+
+// NewSimulation creates a new Simulation with the given rules, map and random seed
+func NewSimulation(rules model.WorldRules, worldMap model.WorldMap, seed int64) *Simulation {
+	return &Simulation{
+		world:   newGameWorld(rules, worldMap, seed),
+		actions: make(map[int64][]func()),
+	}
+}
+
+// NewReplaySimulation creates a new Simulation in the state the replay starts from,
+// with the recorded events scheduled, stepping it for the recorded number of frames plays the round again
+func NewReplaySimulation(replay model.Replay) *Simulation {
+	sim := &Simulation{
+		world:   newReplayWorld(replay),
+		actions: make(map[int64][]func()),
+	}
+	for _, event := range replay.Events {
+		event := event
+		switch {
+		case event.Join != nil:
+			sim.schedule(event.Frame, func() { sim.world.addPlayer(sim.world.replayedPlayer(*event.Join)) })
+		case event.Leave:
+			sim.schedule(event.Frame, func() { sim.world.removePlayer(event.ClientId) })
+		default:
+			controlType := model.Control_KeyUp
+			if event.Down {
+				controlType = model.Control_KeyDown
+			}
+			sim.Control(event.Frame, &model.ControlNotify{
+				ClientId:    event.ClientId,
+				ControlType: controlType,
+				ControlKey:  event.Key,
+				Frame:       event.Seen,
+			})
+		}
+	}
+	return sim
+}
+
+// AddPlayer adds a fake player to the world with the given Client ID, name and color,
+// the player gets the first free skin
+func (sim *Simulation) AddPlayer(clientId, name, color string) error {
+	if _, err := sim.world.admit(name, ""); err != nil {
+		return errors.Wrapf(err, "Cannot add player %s", name)
+	}
+	sim.world.addPlayer(newPlayer(clientId, name, color, ""))
+	return nil
+}
+
+// RemovePlayer removes the player with the given Client ID from the world
+func (sim *Simulation) RemovePlayer(clientId string) {
+	sim.world.removePlayer(clientId)
+}
+
+// Control schedules a control notification to be applied right before the given frame is simulated,
+// the notification's Client ID selects the player
+func (sim *Simulation) Control(frame int64, ctl *model.ControlNotify) {
+	sim.schedule(frame, func() { sim.world.control(ctl) })
+}
+
+// schedule adds an action to be applied right before the given frame is simulated
+func (sim *Simulation) schedule(frame int64, action func()) {
+	sim.actions[frame] = append(sim.actions[frame], action)
+}
+
+// Frame returns the number of the last simulated frame
+func (sim *Simulation) Frame() int64 {
+	sim.world.mu.RLock()
+	defer sim.world.mu.RUnlock()
+
+	return sim.world.frame
+}
+
+// Step simulates the given number of frames and returns the dump of the world after each one
+func (sim *Simulation) Step(frames int) []model.GameWorldDump {
+	dumps := make([]model.GameWorldDump, 0, frames)
+	for i := 0; i < frames; i++ {
+		sim.advance()
+		dumps = append(dumps, sim.world.dump())
+	}
+	return dumps
+}
+
+// advance applies the actions scheduled for the next frame, and simulates it
+func (sim *Simulation) advance() {
+	next := sim.Frame() + 1
+	for _, action := range sim.actions[next] {
+		action()
+	}
+	delete(sim.actions, next)
+
+	sim.world.update()
+	sim.messages = append(sim.messages, sim.world.flush()...)
+	sim.replays = append(sim.replays, sim.world.flushReplays()...)
+}
+
+// Dump returns the current state of the world
+func (sim *Simulation) Dump() model.GameWorldDump {
+	return sim.world.dump()
+}
+
+// Messages returns and clears the messages the world sent to the clients during the steps
+func (sim *Simulation) Messages() []*model.ServerMsg {
+	msgs := sim.messages
+	sim.messages = nil
+	return msgs
+}
+
+// Replays returns and clears the recordings of the rounds finished during the steps
+func (sim *Simulation) Replays() []model.Replay {
+	replays := sim.replays
+	sim.replays = nil
+	return replays
+}
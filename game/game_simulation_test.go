@@ -0,0 +1,51 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Simulation(t *testing.T) {
+	req := require.New(t)
+
+	rules := model.DefaultConf().WorldRules
+	rules.MaxPlayer = 2
+
+	sim := NewSimulation(rules, model.DefaultWorldMap(), 7)
+	req.NoError(sim.AddPlayer("a", "Alice", "red"), "The first player should be added")
+	req.Error(sim.AddPlayer("b", "Alice", "blue"), "A player with a taken name should not be added")
+	req.NoError(sim.AddPlayer("b", "Bob", "blue"), "The second player should be added")
+	req.Error(sim.AddPlayer("c", "Carol", "green"), "A player should not be added to a full world")
+
+	sim.Control(61, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right, Seq: 1})
+	sim.Control(71, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyUp, ControlKey: model.Key_Right, Seq: 2})
+	sim.Control(81, &model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Jump, Seq: 3})
+
+	dumps := sim.Step(60)
+	req.Len(dumps, 60, "Step should return a dump for every frame")
+	req.Equal(int64(60), sim.Frame(), "The simulation should be at the last stepped frame")
+
+	settled := dumps[59].WorldObjects[0]
+	dumps = sim.Step(20)
+	req.True(dumps[9].WorldObjects[0].X > settled.X, "Holding right should move the character right")
+
+	req.Equal(int64(2), dumps[19].Players[0].LastInputSeq, "The last applied input sequence should be dumped")
+
+	landed := dumps[19].WorldObjects[0]
+	dumps = sim.Step(5)
+	req.True(dumps[4].WorldObjects[0].Y < landed.Y, "Holding jump should move the character up")
+
+	sim.Step(10)
+
+	var phases []model.RoundPhase
+	for _, msg := range sim.Messages() {
+		if msg.MsgType == model.ServerMsg_Round {
+			phases = append(phases, msg.Round.Phase)
+		}
+	}
+	req.Equal([]model.RoundPhase{model.Phase_Countdown, model.Phase_Playing}, phases, "The round should start with two players")
+	req.Empty(sim.Messages(), "Messages should be cleared once returned")
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/donbattery/bnj/model"
+)
+
+// teams returns the names of the teams playing in the world, none if the world is not in team mode
+func (gw *gameWorld) teams() []string {
+	return model.Teams[:gw.rules.Teams]
+}
+
+// checkTeam checks if the team can be chosen in the world, an empty team is assigned automatically
+func (gw *gameWorld) checkTeam(team string) error {
+	if team == "" {
+		return nil
+	}
+	for _, t := range gw.teams() {
+		if t == team {
+			return nil
+		}
+	}
+	return errors.Errorf("Team %s is not playing in this room", team)
+}
+
+// assignTeam puts the player without a team into the team with the fewest players
+func (gw *gameWorld) assignTeam(p *player) {
+	teams := gw.teams()
+	if len(teams) == 0 || p.team != "" {
+		return
+	}
+	members := make(map[string]int)
+	for _, player := range gw.players {
+		members[player.team]++
+	}
+	p.team = teams[0]
+	for _, team := range teams {
+		if members[team] < members[p.team] {
+			p.team = team
+		}
+	}
+}
+
+// teammates checks if the two players are in the same team
+func (gw *gameWorld) teammates(a, b *player) bool {
+	return a.team != "" && a.team == b.team
+}
+
+// side returns the team of the player, or its Client ID if there are no teams,
+// the players on the same side win together
+func (gw *gameWorld) side(p *player) string {
+	if p.team != "" {
+		return p.team
+	}
+	return p.clientId
+}
+
+// teamScores returns the total round score of each team, nil if the world is not in team mode
+func (gw *gameWorld) teamScores() map[string]int {
+	teams := gw.teams()
+	if len(teams) == 0 {
+		return nil
+	}
+	scores := make(map[string]int, len(teams))
+	for _, team := range teams {
+		scores[team] = 0
+	}
+	for _, player := range gw.players {
+		if player.team != "" {
+			scores[player.team] += player.roundScore
+		}
+	}
+	return scores
+}
+
+// friendlyFire scores the attacker squashing its teammate, it costs a point
+func (gw *gameWorld) friendlyFire(attacker *player) {
+	attacker.roundScore--
+	attacker.totalScore--
+}
+
+// playerDumps returns the dump of every player with the score of its team
+func (gw *gameWorld) playerDumps() (players []model.PlayerDump) {
+	scores := gw.teamScores()
+	for _, player := range gw.players {
+		dump := player.dump()
+		dump.TeamScore = scores[player.team]
+		players = append(players, dump)
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_AssignTeam(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1111111",
+		"1000001",
+		"1000001",
+		"1000001",
+		"1111111",
+	)
+	gw.rules.Teams = 2
+
+	req.Error(gw.checkTeam("green"), "A team which is not playing should not be chosen")
+	req.NoError(gw.checkTeam("blue"), "A playing team should be chosen")
+
+	var teams []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		p := newPlayer(name, name, "red", "")
+		gw.assignTeam(p)
+		gw.players = append(gw.players, p)
+		teams = append(teams, p.team)
+	}
+	req.Equal([]string{"red", "blue", "red", "blue"}, teams, "The players should be put into the smallest team")
+}
+
+func Test_FriendlyFire(t *testing.T) {
+	tCases := []struct {
+		friendlyFire string
+		squashed     bool
+		score        int
+	}{
+		{friendlyFire: model.FriendlyFire_Harmless, squashed: false, score: 0},
+		{friendlyFire: model.FriendlyFire_Penalty, squashed: true, score: -1},
+	}
+
+	for _, tCase := range tCases {
+		t.Run(tCase.friendlyFire, func(t *testing.T) {
+			req := require.New(t)
+
+			gw := testWorld(
+				"1111111",
+				"1000001",
+				"1000001",
+				"1000001",
+				"1111111",
+			)
+			gw.rules.Teams = 2
+			gw.rules.FriendlyFire = tCase.friendlyFire
+			gw.round.phase = model.Phase_Playing
+			attacker := newPlayer("a", "Alice", "red", "")
+			attacker.team = "red"
+			attacker.char = newGameObject("1", "a", "vita", 40, 34, 16)
+			attacker.char.vector.y = 2
+			victim := newPlayer("b", "Bob", "blue", "")
+			victim.team = "red"
+			victim.char = newGameObject("2", "b", "vita", 40, 48, 16)
+			gw.players = []*player{attacker, victim}
+			gw.objects = []*gameObject{attacker.char, victim.char}
+
+			gw.resolveCombat()
+			req.Equal(tCase.squashed, victim.dead > 0, "The teammate should be squashed only with penalty")
+			req.Equal(tCase.score, attacker.roundScore, "The friendly fire should cost a point only with penalty")
+		})
+	}
+}
+
+func Test_TeamWinner(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld("1111")
+	gw.rules.Teams = 2
+	gw.rules.TargetScore = 5
+	scores := map[string]int{"a": 2, "b": 3, "c": 4}
+	teams := map[string]string{"a": "red", "b": "red", "c": "blue"}
+	for _, name := range []string{"a", "b", "c"} {
+		p := newPlayer(name, name, "red", "")
+		p.team, p.roundScore = teams[name], scores[name]
+		gw.players = append(gw.players, p)
+	}
+
+	winner := targetWinner(gw)
+	req.NotNil(winner, "The team reaching the target score together should win")
+	req.Equal("b", winner.name, "The best player of the winning team should be the winner")
+	req.Equal(5, gw.playerDumps()[0].TeamScore, "The team total should be dumped with the players")
+}
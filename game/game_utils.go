@@ -2,6 +2,6 @@ package game
 
 import "math/rand"
 
-func randInt(min, max int) int {
-	return rand.Intn(max-min+1) + min
+func randInt(rng *rand.Rand, min, max int) int {
+	return rng.Intn(max-min+1) + min
 }
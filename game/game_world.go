@@ -1,16 +1,22 @@
 package game
 
 import (
+	"math/rand"
+	"strconv"
 	"sync"
 
+	"github.com/pkg/errors"
+
 	log "github.com/donbattery/bnj/logger"
 	"github.com/donbattery/bnj/model"
 	"github.com/donbattery/bnj/utils"
-	"github.com/rs/xid"
 )
 
 const SafeDistance = 35
 
+// safePlaceTries is the number of random places tried while looking for a safe place for a character
+const safePlaceTries = 100
+
 type gameWorld struct {
 	mu       sync.RWMutex
 	rules    model.WorldRules
@@ -18,13 +24,44 @@ type gameWorld struct {
 	players  []*player
 	objects  []*gameObject
 	rect     *rect
+	round    round
+	// frame is the number of updates since the creation of the world
+	frame int64
+	// seed of the random number generator, the same seed and inputs always produce the same world,
+	// it is replaced with a new one at the start of every round
+	seed int64
+	rng  *rand.Rand
+	// lastId is the ID of the last created game object
+	lastId int
+	// lastBot is the number of the last created bot
+	lastBot int
+	// outbox collects the messages to be sent to the clients after the update
+	outbox []*model.ServerMsg
+	// past keeps the states of the characters in the last frames for the lag compensation
+	past *rewindBuffer
+	// playlist are the levels the world rotates through, and playing is the index of the current one
+	playlist []model.Level
+	playing  int
+	// mode is the scoring and the win conditions of the rounds
+	mode GameMode
+	// recording is the replay of the round being played, and replays are the finished ones waiting to be saved
+	recording *model.Replay
+	replays   []model.Replay
+	// participants are the players who took part in the round being played, including the ones who left it
+	participants []*player
 }
 
-func newGameWorld(rules model.WorldRules, worldMap model.WorldMap) *gameWorld {
+func newGameWorld(rules model.WorldRules, worldMap model.WorldMap, seed int64) *gameWorld {
 	return &gameWorld{
+		seed:     seed,
+		rng:      rand.New(rand.NewSource(seed)),
 		rules:    rules,
 		worldMap: worldMap,
 		rect:     newRect(0, 0, len(worldMap.Rows[0])*rules.BlockSize, len(worldMap.Rows)*rules.BlockSize),
+		round:    round{phase: model.Phase_Waiting},
+		past:     newRewindBuffer(rules.RewindWindow),
+		playing:  -1,
+		mode:     newGameMode(rules.GameMode),
 	}
 }
 
@@ -37,15 +74,11 @@ func (gw *gameWorld) dump() model.GameWorldDump {
 		objects = append(objects, obj.dump())
 	}
 
-	var players []model.PlayerDump
-	for _, player := range gw.players {
-		players = append(players, player.dump())
-	}
-
 	return model.GameWorldDump{
+		Round:        gw.roundDump(),
 		WorldRules:   gw.rules,
 		WorldMap:     gw.worldMap,
-		Players:      players,
+		Players:      gw.playerDumps(),
 		WorldObjects: objects,
 	}
 }
@@ -64,69 +97,292 @@ func (gw *gameWorld) playerDump() (players []model.PlayerDump) {
 	gw.mu.RLock()
 	defer gw.mu.RUnlock()
 
+	return gw.playerDumps()
+}
+
+// update advances the game world by a single frame
+func (gw *gameWorld) update() {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	// The bots come and go in the same critical section as the frame, so the recorded joins and leaves of the humans
+	// cannot get between them, and the bots join and leave on the same frames when the round is replayed
+	gw.balanceBots()
+
+	gw.frame++
+
+	gw.thinkBots()
+
 	for _, player := range gw.players {
-		players = append(players, player.dump())
+		if player.invulnerable > 0 {
+			player.invulnerable--
+		}
+		if player.char == nil {
+			continue
+		}
+		if player.dead > 0 {
+			if player.dead--; player.dead == 0 {
+				gw.respawn(player)
+			}
+			continue
+		}
+		player.input.steer(player.char, player.jumpSpeed())
+		gw.applyEffect(player)
+	}
+
+	for _, obj := range gw.objects {
+		switch {
+		case obj.particle():
+			gw.moveParticle(obj)
+		case obj.pickup():
+			// the items float where they are spawned
+		default:
+			gw.applyPhysics(obj)
+		}
 	}
+
+	gw.resolveCombat()
+	gw.collectPickups()
+	gw.expireParticles()
+	gw.spawnPickups()
+
+	for _, player := range gw.players {
+		if player.char != nil {
+			player.char.animate(player.dead > 0)
+		}
+	}
+
+	gw.updateRound()
+
+	gw.past.record(gw.frame, gw.players)
+}
+
+// flush returns and clears the messages collected during the updates
+func (gw *gameWorld) flush() (msgs []*model.ServerMsg) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	msgs, gw.outbox = gw.outbox, nil
 	return
 }
 
+// control applies a control notification to the held keys of the sending player,
+// the notifications may arrive out of order, so the ones older than the last applied sequence number are dropped
+func (gw *gameWorld) control(ctl *model.ControlNotify) {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+
+	for _, player := range gw.players {
+		if player.clientId == ctl.ClientId {
+			if ctl.Seq != 0 && ctl.Seq <= player.lastSeq {
+				log.Debugf("Stale control notification %d from client %s, the last applied one is %d", ctl.Seq, ctl.ClientId, player.lastSeq)
+				return
+			}
+			held, lag := player.input, player.lag
+			player.input.apply(ctl)
+			if ctl.Seq != 0 {
+				player.lastSeq, player.lastTime = ctl.Seq, ctl.ClientTime
+			}
+			player.lag = gw.lagOf(ctl.Frame)
+			// Repeated key presses change nothing, they are left out of the recording
+			if player.input != held || player.lag != lag {
+				gw.recordControl(ctl)
+			}
+			return
+		}
+	}
+	log.Debugf("Control notification from client %s who is not in the game", ctl.ClientId)
+}
+
+// admit checks if a new player with the given name can join the world and the team,
+// if not it returns the reason and the matching response status
+func (gw *gameWorld) admit(name, team string) (model.ServerResponseStatus, error) {
+	gw.mu.RLock()
+	defer gw.mu.RUnlock()
+
+	// Bots are leaving as humans join, so they do not take the place of humans
+	if gw.humans() >= gw.rules.MaxPlayer {
+		return model.ResponseStatusNotAccaptable, errors.New("Server is full")
+	}
+
+	// Check if a player with the same name is already connected to the game
+	if gw.nameTaken(name) {
+		return model.ResponseStatusUnauthorized, errors.Errorf("Someone is already connected with the name %s", name)
+	}
+
+	if err := gw.checkTeam(team); err != nil {
+		return model.ResponseStatusNotAccaptable, err
+	}
+
+	return model.ResponseStatusAccepted, nil
+}
+
+// nameTaken checks if a player of the world has the given name, the caller must hold the lock
+func (gw *gameWorld) nameTaken(name string) bool {
+	for _, player := range gw.players {
+		if player.name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (gw *gameWorld) addPlayer(p *player) {
 	gw.mu.Lock()
-	defer gw.spawnChar(p)
 	defer gw.mu.Unlock()
+
+	gw.join(p)
+}
+
+// join adds the player to the world and places its character, the caller must hold the lock
+func (gw *gameWorld) join(p *player) {
+	// Give a skin to the player if it did not choose one
+	if p.skin == "" {
+		p.skin = gw.freeSkin()
+	}
+	// Put the player into a team if it did not choose one
+	gw.assignTeam(p)
 	// Add the player to the list of players
 	gw.players = append(gw.players, p)
+	gw.recordJoin(p)
+	gw.placeChar(p)
+	if gw.round.phase == model.Phase_Playing {
+		gw.mode.Join(gw, p)
+		gw.participants = append(gw.participants, p)
+	}
+}
+
+// freeSkin returns the first skin nobody uses, if every skin is taken the least used one
+func (gw *gameWorld) freeSkin() string {
+	used := make(map[string]int)
+	for _, player := range gw.players {
+		used[player.skin]++
+	}
+	skin := model.Skins[0]
+	for _, s := range model.Skins {
+		if used[s] < used[skin] {
+			skin = s
+		}
+	}
+	return skin
 }
 
 func (gw *gameWorld) removePlayer(clientId string) {
 	gw.mu.Lock()
 	defer gw.mu.Unlock()
 
-	found := false
+	for _, player := range gw.players {
+		if player.clientId == clientId {
+			gw.leave(player)
+			return
+		}
+	}
+}
 
-	// Remove the player if he/she is in the game
+// leave removes the player and its objects from the world, the caller must hold the lock
+func (gw *gameWorld) leave(p *player) {
 	for i, player := range gw.players {
-		if player.clientId == clientId {
-			log.Debugf("Removing player with client ID %s from the game", clientId)
+		if player == p {
+			log.Debugf("Removing player with client ID %s from the game", p.clientId)
+			gw.recordLeave(p)
 			gw.players = append(gw.players[:i], gw.players[i+1:]...)
-			found = true
 			break
 		}
 	}
 
-	// If player is removed remove any belonging child element
-	if found {
-		for i, obj := range gw.objects {
-			if obj.parentId == clientId {
-				gw.objects = append(gw.objects[:i], gw.objects[i+1:]...)
-			}
+	// Remove any belonging child element
+	var objects []*gameObject
+	for _, obj := range gw.objects {
+		if obj.parentId != p.clientId {
+			objects = append(objects, obj)
 		}
 	}
+	gw.objects = objects
 }
 
-func (gw *gameWorld) spawnChar(p *player) {
-	gw.mu.Lock()
-	defer gw.mu.Unlock()
+// humans returns the number of players who are not bots
+func (gw *gameWorld) humans() (count int) {
+	for _, player := range gw.players {
+		if player.bot == nil {
+			count++
+		}
+	}
+	return
+}
 
-	x, y := gw.findSafePlace(gw.rules.BlockSize)
-	gw.objects = append(gw.objects, newGameObject(xid.New().String(), p.clientId, "vita", x, y))
+// placeChar creates the player's character at a safe place,
+// without a place to spawn on the player is left out until the next round
+func (gw *gameWorld) placeChar(p *player) {
+	x, y, ok := gw.spawnPlace(gw.rules.BlockSize)
+	if !ok {
+		log.Warnf("No place to spawn %s, the player is out until the next round", p.name)
+		return
+	}
+	p.char = newGameObject(gw.nextId(), p.clientId, "vita", x, y, gw.rules.BlockSize)
+	p.char.owner = p.name
+	p.char.skin = p.skin
+	gw.objects = append(gw.objects, p.char)
 }
 
-func (gw *gameWorld) findSafePlace(size int) (x float64, y float64) {
-	for {
-		x = float64(randInt(0, gw.rect.width-size))
-		y = float64(randInt(0, gw.rect.height-size))
-		log.Infof("Next X %f Y %f Size %d", x, y, size)
+// eliminate removes the player's character from the world until the next round
+func (gw *gameWorld) eliminate(p *player) {
+	log.Debugf("%s is out of the round", p.name)
+	for i, obj := range gw.objects {
+		if obj == p.char {
+			gw.objects = append(gw.objects[:i], gw.objects[i+1:]...)
+			break
+		}
+	}
+	// The eliminated player must not be respawned when its death is over
+	p.char = nil
+	p.dead = 0
+}
+
+// revive gives a character to the eliminated players
+func (gw *gameWorld) revive() {
+	for _, player := range gw.players {
+		if player.char == nil {
+			gw.placeChar(player)
+		}
+	}
+}
+
+// hasTile checks if the map has any tile with the given code
+func (gw *gameWorld) hasTile(tile int) bool {
+	for _, row := range gw.worldMap.Rows {
+		for _, t := range row {
+			if int(t) == tile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextId returns a new game object ID, which is unique within the world
+func (gw *gameWorld) nextId() string {
+	gw.lastId++
+	return strconv.Itoa(gw.lastId)
+}
+
+// findSafePlace looks for an empty place far enough from the other objects at random,
+// it gives up after the given number of tries, as a crowded or cramped map may have no such place at all
+func (gw *gameWorld) findSafePlace(size, tries int) (x float64, y float64, ok bool) {
+	for i := 0; i < tries; i++ {
+		x = float64(randInt(gw.rng, 0, gw.rect.width-size))
+		y = float64(randInt(gw.rng, 0, gw.rect.height-size))
+		log.Debugf("Next X %f Y %f Size %d", x, y, size)
 		if gw.isEmpty(x, y, size) && gw.isSafe(x, y, size) {
-			return
+			return x, y, true
 		}
 	}
+	return 0, 0, false
 }
 
 func (gw *gameWorld) isEmpty(x, y float64, size int) bool {
 	for offY := 0; offY <= size; {
 		for offX := 0; offX <= size; {
-			if gw.worldMap.GetFloat(x+float64(offX), y+float64(offY), gw.rules.BlockSize) != 48 {
+			if gw.worldMap.GetFloat(x+float64(offX), y+float64(offY), gw.rules.BlockSize) != model.Tile_Empty {
 				return false
 			}
 			delta := size - offX
@@ -148,6 +404,9 @@ func (gw *gameWorld) isEmpty(x, y float64, size int) bool {
 
 func (gw *gameWorld) isSafe(x, y float64, size int) bool {
 	for _, obj := range gw.objects {
+		if obj.particle() {
+			continue
+		}
 		if utils.Distance(x+float64((size/2)), y+float64((size/2)), obj.x+float64((size/2)), obj.y+float64((size/2))) < SafeDistance {
 			return false
 		}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Deterministic(t *testing.T) {
+	req := require.New(t)
+
+	controls := map[int64][]*model.ControlNotify{
+		10: {{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right}},
+		20: {{ClientId: "b", ControlType: model.Control_KeyDown, ControlKey: model.Key_Jump}},
+		40: {{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Jump}},
+		45: {{ClientId: "b", ControlType: model.Control_KeyDown, ControlKey: model.Key_Left}},
+		80: {{ClientId: "a", ControlType: model.Control_KeyUp, ControlKey: model.Key_Right}},
+	}
+
+	newWorld := func() *gameWorld {
+		gw := newGameWorld(model.DefaultConf().WorldRules, model.DefaultWorldMap(), 42)
+		gw.addPlayer(newPlayer("a", "Alice", "red", ""))
+		gw.addPlayer(newPlayer("b", "Bob", "blue", ""))
+		return gw
+	}
+
+	worldA, worldB := newWorld(), newWorld()
+	req.Equal(worldA.dump(), worldB.dump(), "Worlds with the same seed should start the same")
+
+	for frame := int64(1); frame <= 300; frame++ {
+		for _, ctl := range controls[frame] {
+			worldA.control(ctl)
+			worldB.control(ctl)
+		}
+		worldA.update()
+		worldB.update()
+		req.Equal(worldA.dump(), worldB.dump(), "Worlds with the same seed and inputs should be the same at frame %d", frame)
+	}
+}
+
+func Test_NoPlaceToSpawn(t *testing.T) {
+	req := require.New(t)
+
+	gw := testWorld(
+		"1111",
+		"1001",
+		"1111",
+	)
+	a, b := newPlayer("a", "Alice", "red", ""), newPlayer("b", "Bob", "blue", "")
+	gw.addPlayer(a)
+	gw.addPlayer(b)
+	req.Nil(a.char, "The player should be left out without a place to spawn on")
+	for i := 0; i < FrameRate*10; i++ {
+		gw.update()
+	}
+	req.Nil(b.char, "The player should be left out of the rounds without a place to spawn on")
+
+	gw.worldMap.Spawns = []model.Spawn{{Col: 1, Row: 1}}
+	gw.revive()
+	req.NotNil(a.char, "The player should spawn on the spawn point")
+	req.NotNil(b.char, "The player should spawn on the spawn point even if it is not safe")
+	req.Equal([]float64{16, 16}, []float64{b.char.x, b.char.y}, "The player should spawn on the spawn point")
+	gw.respawn(a)
+	req.Equal([]float64{16, 16}, []float64{a.char.x, a.char.y}, "The character should respawn on the spawn point")
+}
+
+func Test_StaleControl(t *testing.T) {
+	req := require.New(t)
+
+	gw := newGameWorld(model.DefaultConf().WorldRules, model.DefaultWorldMap(), 1)
+	p := newPlayer("a", "Alice", "red", "")
+	gw.addPlayer(p)
+
+	// The key is released after it is pressed, but the notifications arrive the other way around
+	gw.control(&model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyUp, ControlKey: model.Key_Right, Seq: 2})
+	gw.control(&model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right, Seq: 1})
+	req.False(p.input.right, "The stale key press should not be applied")
+	req.Equal(int64(2), p.lastSeq, "The last applied sequence number should be kept")
+
+	gw.control(&model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyDown, ControlKey: model.Key_Right, Seq: 3})
+	req.True(p.input.right, "The newer key press should be applied")
+	gw.control(&model.ControlNotify{ClientId: "a", ControlType: model.Control_KeyUp, ControlKey: model.Key_Right})
+	req.False(p.input.right, "The notifications without a sequence number should be applied")
+}
@@ -37,6 +37,7 @@ type NotifyType string
 const (
 	Notify_Chat    NotifyType = "chat"
 	Notify_Control NotifyType = "control"
+	Notify_Ack     NotifyType = "ack"
 )
 
 // ChatNotify is a client chat notification
@@ -45,10 +46,39 @@ type ChatNotify struct {
 	Message string `json:"message"`
 }
 
-// ControlNotify is an user control notification (key down or key up)
+type ControlType string
+
+const (
+	Control_KeyDown ControlType = "keydown"
+	Control_KeyUp   ControlType = "keyup"
+)
+
+type ControlKey string
+
+const (
+	Key_Left  ControlKey = "left"
+	Key_Right ControlKey = "right"
+	Key_Jump  ControlKey = "jump"
+)
+
+// ControlNotify is an user control notification (key down or key up),
+// Seq is increased by the client with every notification, and ClientTime is the client's clock in milliseconds,
+// the client can predict the movement of its character and reconcile it with the last applied Seq of the server,
+// the notifications arriving after a newer one are dropped.
+// Frame is the last world update the client received, the combat is resolved as the client saw the world
 type ControlNotify struct {
-	ControlType string `json:"control_type"`
-	ControlKey  string `json:"control_key"`
+	ClientId    string      `json:"-"`
+	ControlType ControlType `json:"control_type"`
+	ControlKey  ControlKey  `json:"control_key"`
+	Seq         int64       `json:"seq"`
+	ClientTime  int64       `json:"client_time"`
+	Frame       int64       `json:"frame"`
+}
+
+// AckNotify acknowledges the receipt of the world update of a frame,
+// the next world updates will be relative to the last acknowledged one
+type AckNotify struct {
+	Frame int64 `json:"frame"`
 }
 
 // ClientNotify is a push message to the hub by a client
@@ -56,6 +86,7 @@ type ClientNotify struct {
 	NotifyType NotifyType     `json:"notify_type"`
 	Chat       *ChatNotify    `json:"chat,omitempty"`
 	Control    *ControlNotify `json:"control,omitempty"`
+	Ack        *AckNotify     `json:"ack,omitempty"`
 }
 
 // ClientRequest is a request to the hub by a client
@@ -7,12 +7,33 @@ type Config struct {
 	Port       int        `json:"port"        yaml:"port"        mapstructure:"port"`
 	DataBase   DataBase   `json:"database"    yaml:"database"    mapstructure:"database"`
 	WorldRules WorldRules `json:"world_rules" yaml:"world_rules" mapstructure:"world_rules"`
+	Admin      Admin      `json:"admin"       yaml:"admin"       mapstructure:"admin"`
+	// MaxReplays is the number of round replays kept in the matches bucket, the replays of the older matches
+	// are deleted, but their records are kept. With 0 no replays are kept
+	MaxReplays int `json:"max_replays" yaml:"max_replays" mapstructure:"max_replays"`
+	// MaxRooms is the number of rooms that can be open at the same time, the default room included
+	MaxRooms int `json:"max_rooms" yaml:"max_rooms" mapstructure:"max_rooms"`
+}
+
+// Admin is the credential of the administrative endpoints, without a password they are disabled
+type Admin struct {
+	User     string `json:"user" yaml:"user"     mapstructure:"user"`
+	Password string `json:"-"    yaml:"password" mapstructure:"password"`
+}
+
+// Enabled checks if the administrative endpoints can be used
+func (admin Admin) Enabled() bool {
+	return admin.User != "" && admin.Password != ""
 }
 
 // Validate the server configurations
 func (conf Config) Validate() error {
 	return validation.ValidateStruct(&conf,
 		validation.Field(&conf.Port, validation.Required, validation.Min(1000), validation.Max(9999)),
+		validation.Field(&conf.WorldRules),
+		validation.Field(&conf.Admin),
+		validation.Field(&conf.MaxReplays, validation.Min(0)),
+		validation.Field(&conf.MaxRooms, validation.Required, validation.Min(1)),
 	)
 }
 
@@ -26,11 +47,37 @@ func DefaultConf() Config {
 			URL:  "bnj.db",
 		},
 		WorldRules: WorldRules{
-			BlockSize:   16,
-			MaxPlayer:   10,
-			MinPlayer:   2,
-			TargetScore: 33,
-			WaitTime:    90,
+			BlockSize:    16,
+			MaxPlayer:    10,
+			MinPlayer:    2,
+			TargetScore:  33,
+			WaitTime:     90,
+			Gravity:      0.5,
+			Friction:     0.2,
+			BotLevel:     BotLevel_Normal,
+			RewindWindow: 15,
+			MaxRewind:    6,
+			MaxParticles: 64,
+			Pickups:      []string{Pickup_Jetpack, Pickup_Boots, Pickup_Shield},
+			PickupRate:   10,
+			MaxPickups:   2,
+			PickupTime:   10,
+			Rotation:     Rotation_Playlist,
+			GameMode:     Mode_Deathmatch,
+			Lives:        3,
+			FriendlyFire: FriendlyFire_Harmless,
 		},
+		Admin: Admin{
+			User: "admin",
+		},
+		MaxReplays: 100,
+		MaxRooms:   32,
 	}
 }
+
+// Validate the Admin credential
+func (admin Admin) Validate() error {
+	return validation.ValidateStruct(&admin,
+		validation.Field(&admin.Password, validation.Length(8, 0)),
+	)
+}
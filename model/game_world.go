@@ -3,10 +3,11 @@ package model
 import (
 	"math"
 
-	log "github.com/donbattery/bnj/logger"
+	validation "github.com/go-ozzo/ozzo-validation"
 )
 
 type GameWorldDump struct {
+	Round        RoundUpdate      `json:"round"`
 	WorldRules   WorldRules       `json:"world_rules"`
 	WorldMap     WorldMap         `json:"world_map"`
 	Players      []PlayerDump     `json:"players"`
@@ -21,17 +22,142 @@ type WorldRules struct {
 	WaitTime    int     `json:"wait_time"    yaml:"wait_time"    mapstructure:"wait_time"`
 	Gravity     float64 `json:"gravity"      yaml:"gravity"      mapstructure:"gravity"`
 	Friction    float64 `json:"friction"     yaml:"friction"     mapstructure:"friction"`
+	BotLevel    string  `json:"bot_level"    yaml:"bot_level"    mapstructure:"bot_level"`
+	// RewindWindow is the number of past frames kept to compensate the lag of the players
+	RewindWindow int `json:"rewind_window" yaml:"rewind_window" mapstructure:"rewind_window"`
+	// MaxRewind is the maximum number of frames the combat is rewound for a lagging player, at most the RewindWindow
+	MaxRewind int `json:"max_rewind" yaml:"max_rewind" mapstructure:"max_rewind"`
+	// MaxParticles is the maximum number of live particles (fur, blood, splash and dust) in the world
+	MaxParticles int `json:"max_particles" yaml:"max_particles" mapstructure:"max_particles"`
+	// Pickups are the enabled items, which can be collected during the rounds
+	Pickups []string `json:"pickups" yaml:"pickups" mapstructure:"pickups"`
+	// PickupRate is the average number of seconds between two item spawns, with 0 no items are spawned
+	PickupRate int `json:"pickup_rate" yaml:"pickup_rate" mapstructure:"pickup_rate"`
+	// MaxPickups is the maximum number of items waiting to be collected
+	MaxPickups int `json:"max_pickups" yaml:"max_pickups" mapstructure:"max_pickups"`
+	// PickupTime is the number of seconds the effect of a collected item lasts for
+	PickupTime int `json:"pickup_time" yaml:"pickup_time" mapstructure:"pickup_time"`
+	// Level is the name of the level in the levels bucket the room starts on, without it the default map is used
+	Level string `json:"level" yaml:"level" mapstructure:"level"`
+	// Playlist are the names of the levels the room rotates through between the rounds
+	Playlist []string `json:"playlist" yaml:"playlist" mapstructure:"playlist"`
+	// Rotation selects the next level from the Playlist, in order or at random, or disables the rotation
+	Rotation string `json:"rotation" yaml:"rotation" mapstructure:"rotation"`
+	// GameMode selects the scoring and the win conditions of the rounds
+	GameMode string `json:"game_mode" yaml:"game_mode" mapstructure:"game_mode"`
+	// Lives is the number of times a player can be squashed in a last rabbit standing round, that mode needs at least 1
+	Lives int `json:"lives" yaml:"lives" mapstructure:"lives"`
+	// Teams is the number of teams in team mode, with 0 everyone plays for itself
+	Teams int `json:"teams" yaml:"teams" mapstructure:"teams"`
+	// FriendlyFire selects what happens when a player stomps its teammate, it is harmless or it costs a point
+	FriendlyFire string `json:"friendly_fire" yaml:"friendly_fire" mapstructure:"friendly_fire"`
+}
+
+// The difficulty levels of the bots, with BotLevel_None no bots join the game
+const (
+	BotLevel_None   = "none"
+	BotLevel_Easy   = "easy"
+	BotLevel_Normal = "normal"
+	BotLevel_Hard   = "hard"
+)
+
+// BotClientIdPrefix starts the Client IDs of the bots, the clients cannot connect with such an ID
+const BotClientIdPrefix = "bot:"
+
+// The collectible items, the jetpack lifts its wearer while the jump key is held,
+// the boots make the jumps higher, and the shield protects from being squashed
+const (
+	Pickup_Jetpack = "jetpack"
+	Pickup_Boots   = "boots"
+	Pickup_Shield  = "shield"
+)
+
+// The map rotations, with Rotation_None the room stays on its level
+const (
+	Rotation_None     = "none"
+	Rotation_Playlist = "playlist"
+	Rotation_Random   = "random"
+)
+
+// Rewind_MaxWindow is the largest RewindWindow, 3 seconds of frames at 30 frames per second,
+// the past states of the characters are kept in memory for every frame of the window
+const Rewind_MaxWindow = 90
+
+// MaxLives is the largest number of lives a room creator can give the players
+const MaxLives = 99
+
+// The game modes, in deathmatch every squash scores, in king of the hill every second spent alone on the hill scores,
+// and in last rabbit standing the players have a number of lives, and the last one with any lives left wins
+const (
+	Mode_Deathmatch    = "deathmatch"
+	Mode_KingOfTheHill = "king_of_the_hill"
+	Mode_LastRabbit    = "last_rabbit"
+)
+
+// Teams are the names of the teams, in team mode the first WorldRules.Teams of them are playing
+var Teams = []string{"red", "blue", "green", "yellow"}
+
+// The friendly fire rules, a stomp on a teammate is either harmless or it costs a point
+const (
+	FriendlyFire_Harmless = "harmless"
+	FriendlyFire_Penalty  = "penalty"
+)
+
+// Validate the WorldRules
+func (rules WorldRules) Validate() error {
+	// Every player needs a life to start a last rabbit standing round with
+	livesRules := []validation.Rule{validation.Min(0)}
+	if rules.GameMode == Mode_LastRabbit {
+		livesRules = []validation.Rule{validation.Required, validation.Min(1)}
+	}
+	return validation.ValidateStruct(&rules,
+		validation.Field(&rules.BlockSize, validation.Required, validation.Min(1)),
+		validation.Field(&rules.MaxPlayer, validation.Required, validation.Min(1)),
+		validation.Field(&rules.MinPlayer, validation.Max(rules.MaxPlayer)),
+		validation.Field(&rules.BotLevel, validation.In(BotLevel_None, BotLevel_Easy, BotLevel_Normal, BotLevel_Hard)),
+		validation.Field(&rules.RewindWindow, validation.Min(0), validation.Max(Rewind_MaxWindow)),
+		validation.Field(&rules.MaxRewind, validation.Min(0), validation.Max(rules.RewindWindow)),
+		validation.Field(&rules.MaxParticles, validation.Min(0)),
+		validation.Field(&rules.Pickups, validation.Each(validation.In(Pickup_Jetpack, Pickup_Boots, Pickup_Shield))),
+		validation.Field(&rules.PickupRate, validation.Min(0)),
+		validation.Field(&rules.MaxPickups, validation.Min(0)),
+		validation.Field(&rules.PickupTime, validation.Min(0)),
+		validation.Field(&rules.Level, validation.Match(LevelName)),
+		validation.Field(&rules.Playlist, validation.Each(validation.Match(LevelName))),
+		validation.Field(&rules.Rotation, validation.In(Rotation_None, Rotation_Playlist, Rotation_Random)),
+		validation.Field(&rules.GameMode, validation.In(Mode_Deathmatch, Mode_KingOfTheHill, Mode_LastRabbit)),
+		validation.Field(&rules.Lives, livesRules...),
+		validation.Field(&rules.Teams, validation.Min(0), validation.Max(len(Teams))),
+		validation.Field(&rules.FriendlyFire, validation.In(FriendlyFire_Harmless, FriendlyFire_Penalty)),
+	)
 }
 
 type PlayerDump struct {
 	Name       string `json:"name"`
 	Color      string `json:"color"`
+	Skin       string `json:"skin"`
+	Bot        bool   `json:"bot"`
 	RoundWins  int    `json:"round_wins"`
 	RoundScore int    `json:"round_score"`
 	TotalScore int    `json:"total_score"`
+	// LastInputSeq is the sequence number of the last control notification applied to the player
+	LastInputSeq int64 `json:"last_input_seq"`
+	// LastInputTime is the client time of the last control notification applied to the player
+	LastInputTime int64 `json:"last_input_time"`
+	// Effect is the item the player is using, and EffectTime is the number of seconds it lasts for
+	Effect     string `json:"effect,omitempty"`
+	EffectTime int    `json:"effect_time,omitempty"`
+	// Lives is the number of lives left in a last rabbit standing round
+	Lives int `json:"lives,omitempty"`
+	// Team is the name of the player's team, and TeamScore is the round score of the team in team mode
+	Team      string `json:"team,omitempty"`
+	TeamScore int    `json:"team_score,omitempty"`
 }
 
 type GameObjectDump struct {
+	Id      string `json:"id"`
+	Owner   string `json:"owner,omitempty"`
+	Skin    string `json:"skin,omitempty"`
 	ObjType string `json:"obj_type"`
 	Anim    int    `json:"anim"`
 	X       int    `json:"x"`
@@ -40,26 +166,42 @@ type GameObjectDump struct {
 	FlipY   bool   `json:"flip_y"`
 }
 
+// The tile codes of the WorldMap rows
+const (
+	Tile_Empty  = '0'
+	Tile_Solid  = '1'
+	Tile_Water  = '2'
+	Tile_Ice    = '3'
+	Tile_Spring = '4'
+	Tile_Hill   = '5'
+)
+
+// SolidTile checks if a tile code is blocking movement
+func SolidTile(tile int) bool {
+	return tile != Tile_Empty && tile != Tile_Water && tile != Tile_Hill
+}
+
 type WorldMap struct {
 	Background string   `json:"background"`
 	Rows       []string `json:"rows"`
+	// Spawns are the places where the characters spawn, without them the characters spawn at random places
+	Spawns []Spawn `json:"spawns,omitempty"`
 }
 
 func (wm WorldMap) GetFloat(x, y float64, size int) int {
 	col := int(math.Floor(x / float64(size)))
 	row := int(math.Floor(y / float64(size)))
-	log.Infof("X %f.2 Y %f.2 ROW %d COL %d", x, y, row, col)
 	if col < 0 {
-		return 49
+		return Tile_Solid
 	}
 	if col >= len(wm.Rows[0]) {
-		return 49
+		return Tile_Solid
 	}
 	if row < 0 {
-		return 48
+		return Tile_Empty
 	}
 	if row >= len(wm.Rows) {
-		return 49
+		return Tile_Solid
 	}
 	return int(wm.Rows[row][col])
 }
@@ -86,3 +86,35 @@ func Test_GetFloat(t *testing.T) {
 			"GetFloat should return %d when the block size is %d X is %f and Y is %f", t_case.required, t_case.size, t_case.x, t_case.y)
 	}
 }
+
+func Test_WorldRulesValidate(t *testing.T) {
+	req := require.New(t)
+
+	t_cases := []struct {
+		name   string
+		modify func(rules *WorldRules)
+		valid  bool
+	}{
+		{name: "default rules", modify: func(rules *WorldRules) {}, valid: true},
+		{name: "deathmatch without lives", modify: func(rules *WorldRules) { rules.Lives = 0 }, valid: true},
+		{name: "last rabbit with lives", modify: func(rules *WorldRules) { rules.GameMode = Mode_LastRabbit }, valid: true},
+		{name: "last rabbit without lives", modify: func(rules *WorldRules) {
+			rules.GameMode, rules.Lives = Mode_LastRabbit, 0
+		}, valid: false},
+		{name: "negative lives", modify: func(rules *WorldRules) { rules.Lives = -1 }, valid: false},
+		{name: "huge rewind window", modify: func(rules *WorldRules) { rules.RewindWindow = 1 << 40 }, valid: false},
+		{name: "largest rewind window", modify: func(rules *WorldRules) { rules.RewindWindow = Rewind_MaxWindow }, valid: true},
+		{name: "max rewind over the window", modify: func(rules *WorldRules) { rules.MaxRewind = rules.RewindWindow + 1 }, valid: false},
+		{name: "unknown game mode", modify: func(rules *WorldRules) { rules.GameMode = "tag" }, valid: false},
+	}
+
+	for _, t_case := range t_cases {
+		rules := DefaultConf().WorldRules
+		t_case.modify(&rules)
+		if t_case.valid {
+			req.NoError(rules.Validate(), "Validate should accept the %s", t_case.name)
+		} else {
+			req.Error(rules.Validate(), "Validate should reject the %s", t_case.name)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+// LevelName is the format of the level names, they are used as keys in the levels bucket
+var LevelName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Level is a custom map stored in the levels bucket
+type Level struct {
+	Name       string   `json:"name"`
+	Background string   `json:"background"`
+	Rows       []string `json:"rows"`
+	TileSize   int      `json:"tile_size"`
+	Spawns     []Spawn  `json:"spawns"`
+}
+
+// Spawn is a place where the characters can (re)spawn, in tiles from the top left corner of the map
+type Spawn struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// Validate the Level
+func (level Level) Validate() error {
+	return validation.ValidateStruct(&level,
+		validation.Field(&level.Name, validation.Required, validation.Length(3, 32), validation.Match(LevelName)),
+		validation.Field(&level.Background, validation.Required),
+		validation.Field(&level.Rows, validation.Required, validation.Length(3, 0), validation.By(validRows)),
+		validation.Field(&level.TileSize, validation.Required, validation.Min(4), validation.Max(64)),
+		validation.Field(&level.Spawns, validation.By(level.validSpawns)),
+	)
+}
+
+// WorldMap returns the WorldMap of the Level
+func (level Level) WorldMap() WorldMap {
+	return WorldMap{
+		Background: level.Background,
+		Rows:       level.Rows,
+		Spawns:     level.Spawns,
+	}
+}
+
+// validRows checks if the rows are equally long, have only known tile codes, and are surrounded by solid tiles
+func validRows(value interface{}) error {
+	rows, _ := value.([]string)
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			return errors.Errorf("row %d is %d tiles long instead of %d", i, len(row), len(rows[0]))
+		}
+		for j, tile := range row {
+			if !knownTile(tile) {
+				return errors.Errorf("unknown tile %q in row %d column %d", tile, i, j)
+			}
+			border := i == 0 || i == len(rows)-1 || j == 0 || j == len(row)-1
+			if border && !SolidTile(int(tile)) {
+				return errors.Errorf("the border must be solid, but row %d column %d is not", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// validSpawns checks if the spawn points are on empty tiles of the map,
+// without spawn points the map must have room for the characters to spawn on
+func (level Level) validSpawns(value interface{}) error {
+	spawns, _ := value.([]Spawn)
+	if len(spawns) == 0 && !level.hasRoom() {
+		return errors.New("the map has no room for a character, it needs spawn points")
+	}
+	for i, spawn := range spawns {
+		if spawn.Row < 0 || spawn.Row >= len(level.Rows) || spawn.Col < 0 || spawn.Col >= len(level.Rows[spawn.Row]) {
+			return errors.Errorf("spawn %d is out of the map", i)
+		}
+		if level.Rows[spawn.Row][spawn.Col] != Tile_Empty {
+			return errors.Errorf("spawn %d is not on an empty tile", i)
+		}
+	}
+	return nil
+}
+
+// hasRoom checks if the map has 2x2 empty tiles anywhere, which is the room a character needs,
+// as a character touches the tiles next to it as well
+func (level Level) hasRoom() bool {
+	empty := func(row, col int) bool {
+		return row < len(level.Rows) && col < len(level.Rows[row]) && level.Rows[row][col] == Tile_Empty
+	}
+	for i, row := range level.Rows {
+		for j := range row {
+			if empty(i, j) && empty(i, j+1) && empty(i+1, j) && empty(i+1, j+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// knownTile checks if the tile code is one of the Tile constants
+func knownTile(tile rune) bool {
+	switch tile {
+	case Tile_Empty, Tile_Solid, Tile_Water, Tile_Ice, Tile_Spring, Tile_Hill:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+)
+
+func Test_LevelValidate(t *testing.T) {
+	req := require.New(t)
+
+	valid := func() Level {
+		return Level{
+			Name:       "arena",
+			Background: "#000000",
+			Rows: []string{
+				"111111",
+				"100001",
+				"100401",
+				"111111",
+			},
+			TileSize: 16,
+			Spawns:   []Spawn{{Col: 1, Row: 1}},
+		}
+	}
+
+	t_cases := []struct {
+		name   string
+		modify func(level *Level)
+		valid  bool
+	}{
+		{name: "valid level", modify: func(level *Level) {}, valid: true},
+		{name: "name with a dot", modify: func(level *Level) { level.Name = "are.na" }, valid: false},
+		{name: "level without spawns", modify: func(level *Level) { level.Spawns = nil }, valid: true},
+		{name: "uneven rows", modify: func(level *Level) { level.Rows[1] = "1000001" }, valid: false},
+		{name: "unknown tile", modify: func(level *Level) { level.Rows[1] = "100901" }, valid: false},
+		{name: "open border", modify: func(level *Level) { level.Rows[0] = "110111" }, valid: false},
+		{name: "water border", modify: func(level *Level) { level.Rows[3] = "122221" }, valid: false},
+		{name: "cramped level without spawns", modify: func(level *Level) {
+			level.Rows, level.Spawns = []string{"1111", "1001", "1111"}, nil
+		}, valid: false},
+		{name: "cramped level with spawns", modify: func(level *Level) {
+			level.Rows, level.Spawns = []string{"1111", "1001", "1111"}, []Spawn{{Col: 1, Row: 1}}
+		}, valid: true},
+		{name: "spawn out of the map", modify: func(level *Level) { level.Spawns[0].Col = 9 }, valid: false},
+		{name: "spawn in a wall", modify: func(level *Level) { level.Spawns[0].Col = 0 }, valid: false},
+		{name: "missing tile size", modify: func(level *Level) { level.TileSize = 0 }, valid: false},
+	}
+
+	for _, t_case := range t_cases {
+		level := valid()
+		t_case.modify(&level)
+		if t_case.valid {
+			req.NoError(level.Validate(), "Validate should accept the %s", t_case.name)
+		} else {
+			req.Error(level.Validate(), "Validate should reject the %s", t_case.name)
+		}
+	}
+}
@@ -2,17 +2,47 @@ package model
 
 import validation "github.com/go-ozzo/ozzo-validation"
 
+// Skins are the characters a player can choose from
+var Skins = []string{"dott", "jiffy", "fizz", "mijji"}
+
 type LoginRequest struct {
 	// ClientID string `json:"client_id"`
 	Name  string `json:"name"`
 	Color string `json:"color"`
+	Skin  string `json:"skin"`
+	Room  string `json:"room"`
+	// Level is the level of the room, if it is created by the login
+	Level string `json:"level"`
+	// GameMode is the game mode of the room, if it is created by the login
+	GameMode string `json:"game_mode"`
+	// Team is the chosen team in team mode, without it the player is put into the smallest team
+	Team string `json:"team"`
 }
 
 // Validate the LoginRequest
 func (req LoginRequest) Validate() error {
 	return validation.ValidateStruct(&req,
 		// validation.Field(&req.ClientID, validation.Required, validation.Length(8, 64)),
-		validation.Field(&req.Name, validation.Required, validation.Length(3, 16)),
+		validation.Field(&req.Name, validation.Required, validation.Length(3, 16), validation.Match(PlayerName)),
 		validation.Field(&req.Color, validation.Required),
+		validation.Field(&req.Skin, validation.In(skinValues()...)),
+		validation.Field(&req.Room, validation.Length(3, 32)),
+		validation.Field(&req.Level, validation.Match(LevelName)),
+		validation.Field(&req.GameMode, validation.In(Mode_Deathmatch, Mode_KingOfTheHill, Mode_LastRabbit)),
+		validation.Field(&req.Team, validation.In(teamValues()...)),
 	)
 }
+
+func skinValues() (values []interface{}) {
+	for _, skin := range Skins {
+		values = append(values, skin)
+	}
+	return
+}
+
+func teamValues() (values []interface{}) {
+	for _, team := range Teams {
+		values = append(values, team)
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// PlayerName is the format of the player names, they are used as keys in the users bucket
+var PlayerName = regexp.MustCompile(`^[^.]+$`)
+
+// MatchRecord is the result of a won round, it is stored in the matches bucket next to the replay of the round
+type MatchRecord struct {
+	Id      string    `json:"id"`
+	Room    string    `json:"room"`
+	Created time.Time `json:"created"`
+	Round   int       `json:"round"`
+	// Level is the name of the level the round was played on, empty for the default map
+	Level    string `json:"level"`
+	GameMode string `json:"game_mode"`
+	// Duration of the round in seconds
+	Duration   int           `json:"duration"`
+	Winner     string        `json:"winner"`
+	WinnerTeam string        `json:"winner_team,omitempty"`
+	Players    []MatchPlayer `json:"players"`
+}
+
+// MatchPlayer is the result of a player at the end of a round
+type MatchPlayer struct {
+	Name   string `json:"name"`
+	Team   string `json:"team,omitempty"`
+	Bot    bool   `json:"bot"`
+	Score  int    `json:"score"`
+	Stomps int    `json:"stomps"`
+	Deaths int    `json:"deaths"`
+}
+
+// PlayerStats are the lifetime statistics of a player, they are stored in the users bucket under the player's name
+type PlayerStats struct {
+	Name   string `json:"name"`
+	Stomps int    `json:"stomps"`
+	Deaths int    `json:"deaths"`
+	Wins   int    `json:"wins"`
+	Games  int    `json:"games"`
+	// TotalScore is the sum of the player's scores in the rounds played to the end
+	TotalScore int       `json:"total_score"`
+	LastPlayed time.Time `json:"last_played"`
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// MatchId is the format of the match IDs, they are used as keys in the matches bucket
+var MatchId = regexp.MustCompile(`^[0-9a-v]{20}$`)
+
+// Replay is the recording of a round: the state of the world at the start of the round
+// and the events changing it, which are enough to simulate the round again exactly.
+// The replays are stored in the matches bucket under their ID
+type Replay struct {
+	Id      string    `json:"id"`
+	Room    string    `json:"room"`
+	Created time.Time `json:"created"`
+	Round   int       `json:"round"`
+	// Seed of the random number generator the round is played with
+	Seed int64 `json:"seed"`
+	// Frame is the frame the round started on, and Frames is the number of frames it was played for
+	Frame      int64      `json:"frame"`
+	Frames     int64      `json:"frames"`
+	Winner     string     `json:"winner"`
+	WorldRules WorldRules `json:"world_rules"`
+	WorldMap   WorldMap   `json:"world_map"`
+	// LastId and LastBot are the counters of the game object IDs and the bot names at the start of the round
+	LastId  int            `json:"last_id"`
+	LastBot int            `json:"last_bot"`
+	Players []ReplayPlayer `json:"players"`
+	Events  []ReplayEvent  `json:"events"`
+	// Record is the result of the round if it is won, it is stored next to the replay
+	Record *MatchRecord `json:"record,omitempty"`
+}
+
+// ReplayPlayer is the state of a player at the start of the round, or when it joined the round
+type ReplayPlayer struct {
+	ClientId   string `json:"client_id"`
+	Name       string `json:"name"`
+	Color      string `json:"color"`
+	Skin       string `json:"skin"`
+	Team       string `json:"team,omitempty"`
+	RoundWins  int    `json:"round_wins"`
+	TotalScore int    `json:"total_score"`
+	// Char is the ID of the player's character, empty if the player was out of the round
+	Char string `json:"char,omitempty"`
+	// Keys are the held keys: l(eft), r(ight) and j(ump)
+	Keys string `json:"keys,omitempty"`
+	Lag  int    `json:"lag,omitempty"`
+	Bot  bool   `json:"bot,omitempty"`
+	// Cooldown is the number of frames until the next decision of a bot
+	Cooldown int `json:"cooldown,omitempty"`
+}
+
+// ReplayEvent is a change made to the world right before a frame is simulated,
+// it is a control notification, a player joining or a player leaving.
+// The events are the bulk of a replay, so their keys are kept short
+type ReplayEvent struct {
+	Frame    int64  `json:"f"`
+	ClientId string `json:"c,omitempty"`
+	// Key is pressed if Down is true, otherwise it is released
+	Key  ControlKey `json:"k,omitempty"`
+	Down bool       `json:"d,omitempty"`
+	// Seen is the last world update the client received when the key was pressed or released
+	Seen  int64         `json:"s,omitempty"`
+	Join  *ReplayPlayer `json:"j,omitempty"`
+	Leave bool          `json:"l,omitempty"`
+}
+
+// The actions controlling the playback of a replay
+const (
+	Playback_Pause  = "pause"
+	Playback_Resume = "resume"
+	Playback_Seek   = "seek"
+	Playback_Speed  = "speed"
+	Playback_Stop   = "stop"
+)
+
+// The slowest and the fastest speed multiplier of a playback
+const (
+	Playback_MinSpeed = 0.25
+	Playback_MaxSpeed = 8.0
+)
+
+// ReplayRequest is the body of a replay request, the replay of the match is played back to the client
+// with the given speed multiplier, without a speed it is played in real time
+type ReplayRequest struct {
+	Match string  `json:"match"`
+	Speed float64 `json:"speed"`
+}
+
+// Validate the ReplayRequest
+func (req ReplayRequest) Validate() error {
+	return validation.ValidateStruct(&req,
+		validation.Field(&req.Match, validation.Required, validation.Match(MatchId)),
+		validation.Field(&req.Speed, validation.Min(Playback_MinSpeed), validation.Max(Playback_MaxSpeed)),
+	)
+}
+
+// PlaybackControl is the body of a playback request, it pauses, resumes or stops the playback,
+// seeks to the Frame (counted from the start of the round), or changes the Speed multiplier
+type PlaybackControl struct {
+	Action string  `json:"action"`
+	Frame  int64   `json:"frame"`
+	Speed  float64 `json:"speed"`
+}
+
+// Validate the PlaybackControl
+func (ctl PlaybackControl) Validate() error {
+	speedRules := []validation.Rule{validation.Min(Playback_MinSpeed), validation.Max(Playback_MaxSpeed)}
+	if ctl.Action == Playback_Speed {
+		speedRules = append(speedRules, validation.Required)
+	}
+	return validation.ValidateStruct(&ctl,
+		validation.Field(&ctl.Action, validation.Required, validation.In(Playback_Pause, Playback_Resume, Playback_Seek, Playback_Speed, Playback_Stop)),
+		validation.Field(&ctl.Frame, validation.Min(0)),
+		validation.Field(&ctl.Speed, speedRules...),
+	)
+}
+
+// PlaybackInfo is the state of a playback, Frame is counted from the start of the round
+type PlaybackInfo struct {
+	Match  string  `json:"match"`
+	Frame  int64   `json:"frame"`
+	Frames int64   `json:"frames"`
+	Speed  float64 `json:"speed"`
+	Paused bool    `json:"paused"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/c2fo/testify/require"
+)
+
+func Test_PlaybackControl(t *testing.T) {
+	req := require.New(t)
+
+	tCases := []struct {
+		ctl   PlaybackControl
+		valid bool
+	}{
+		{ctl: PlaybackControl{Action: Playback_Pause}, valid: true},
+		{ctl: PlaybackControl{Action: Playback_Seek, Frame: 120}, valid: true},
+		{ctl: PlaybackControl{Action: Playback_Seek, Frame: -1}, valid: false},
+		{ctl: PlaybackControl{Action: Playback_Speed, Speed: 2}, valid: true},
+		{ctl: PlaybackControl{Action: Playback_Speed}, valid: false},
+		{ctl: PlaybackControl{Action: Playback_Speed, Speed: 16}, valid: false},
+		{ctl: PlaybackControl{Action: "rewind"}, valid: false},
+	}
+
+	for _, tCase := range tCases {
+		err := tCase.ctl.Validate()
+		if tCase.valid {
+			req.NoError(err, "%+v should be valid", tCase.ctl)
+		} else {
+			req.Error(err, "%+v should be invalid", tCase.ctl)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+// RoomInfo is the public information of a game room, listed by the rooms request
+type RoomInfo struct {
+	Name       string     `json:"name"`
+	Players    int        `json:"players"`
+	Humans     int        `json:"humans"`
+	Spectators int        `json:"spectators"`
+	MaxPlayer  int        `json:"max_player"`
+	Round      int        `json:"round"`
+	Phase      RoundPhase `json:"phase"`
+}
+
+// CreateRoomRequest is the body of a create_room request, the room is created with the server's rules,
+// only the level, the game mode, the number of teams and the lives can be chosen by its creator
+type CreateRoomRequest struct {
+	Name string `json:"name"`
+	// Level is the name of the level the room starts on
+	Level string `json:"level"`
+	// GameMode is the game mode of the room
+	GameMode string `json:"game_mode"`
+	// Teams is the number of teams in team mode, 0 turns the team mode off
+	Teams *int `json:"teams,omitempty"`
+	// Lives is the number of lives in a last rabbit standing round
+	Lives *int `json:"lives,omitempty"`
+}
+
+// Validate the CreateRoomRequest
+func (req CreateRoomRequest) Validate() error {
+	return validation.ValidateStruct(&req,
+		validation.Field(&req.Name, validation.Required, validation.Length(3, 32)),
+		validation.Field(&req.Level, validation.Match(LevelName)),
+		validation.Field(&req.GameMode, validation.In(Mode_Deathmatch, Mode_KingOfTheHill, Mode_LastRabbit)),
+		validation.Field(&req.Teams, validation.Min(0), validation.Max(len(Teams))),
+		validation.Field(&req.Lives, validation.Min(1), validation.Max(MaxLives)),
+	)
+}
+
+// SpectateRequest is the body of a spectate request, without a room name the default room is watched
+type SpectateRequest struct {
+	Room string `json:"room"`
+}
+
+// Validate the SpectateRequest
+func (req SpectateRequest) Validate() error {
+	return validation.ValidateStruct(&req,
+		validation.Field(&req.Room, validation.Length(3, 32)),
+	)
+}
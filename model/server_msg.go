@@ -10,6 +10,8 @@ const (
 	ServerMsg_Chat     ServerMsgType = "chat"
 	ServerMsg_Response ServerMsgType = "response"
 	ServerMsg_Update   ServerMsgType = "update"
+	ServerMsg_Round    ServerMsgType = "round"
+	ServerMsg_Map      ServerMsgType = "map"
 )
 
 type ServerResponseStatus int
@@ -50,9 +52,45 @@ type ServerResponse struct {
 	Payload    string               `json:"payload"`
 }
 
+// WorldUpdate is the state of the world at a frame. A keyframe holds every player and object,
+// otherwise it only holds the players and objects which are changed since the base frame,
+// and the names and IDs of the removed ones
 type WorldUpdate struct {
-	Players      []PlayerDump     `json:"players"`
-	WorldObjects []GameObjectDump `json:"world_objects"`
+	Frame          int64            `json:"frame"`
+	BaseFrame      int64            `json:"base_frame"`
+	Keyframe       bool             `json:"keyframe"`
+	Players        []PlayerDump     `json:"players"`
+	WorldObjects   []GameObjectDump `json:"world_objects"`
+	RemovedPlayers []string         `json:"removed_players,omitempty"`
+	RemovedObjects []string         `json:"removed_objects,omitempty"`
+}
+
+type RoundPhase string
+
+const (
+	Phase_Waiting   RoundPhase = "waiting"
+	Phase_Countdown RoundPhase = "countdown"
+	Phase_Playing   RoundPhase = "playing"
+	Phase_RoundOver RoundPhase = "round_over"
+)
+
+// RoundUpdate is sent to the clients every time the round changes its phase
+type RoundUpdate struct {
+	Round    int          `json:"round"`
+	Phase    RoundPhase   `json:"phase"`
+	Duration int          `json:"duration"`
+	Winner   string       `json:"winner,omitempty"`
+	Players  []PlayerDump `json:"players"`
+	// WinnerTeam is the team of the winner, and Teams are the round scores of the teams in team mode
+	WinnerTeam string         `json:"winner_team,omitempty"`
+	Teams      map[string]int `json:"teams,omitempty"`
+}
+
+// MapUpdate is sent to the clients when the room moves on to the next level of its rotation
+type MapUpdate struct {
+	Level     string   `json:"level"`
+	BlockSize int      `json:"block_size"`
+	WorldMap  WorldMap `json:"world_map"`
 }
 
 // ServerMsg is an object to be sent to one or more clients
@@ -61,6 +99,8 @@ type ServerMsg struct {
 	WorldUpdate *WorldUpdate    `json:"world_update,omitempty"`
 	Chat        *ChatNotify     `json:"chat,omitempty"`
 	Response    *ServerResponse `json:"response,omitempty"`
+	Round       *RoundUpdate    `json:"round,omitempty"`
+	Map         *MapUpdate      `json:"map,omitempty"`
 }
 
 func NewServerMsg(msgType ServerMsgType, worldUpdate *WorldUpdate, chat *ChatNotify, response *ServerResponse) *ServerMsg {
@@ -71,3 +111,19 @@ func NewServerMsg(msgType ServerMsgType, worldUpdate *WorldUpdate, chat *ChatNot
 		Response:    response,
 	}
 }
+
+// NewRoundMsg creates a ServerMsg about the change of the round's phase
+func NewRoundMsg(round *RoundUpdate) *ServerMsg {
+	return &ServerMsg{
+		MsgType: ServerMsg_Round,
+		Round:   round,
+	}
+}
+
+// NewMapMsg creates a ServerMsg about the change of the map
+func NewMapMsg(mapUpdate *MapUpdate) *ServerMsg {
+	return &ServerMsg{
+		MsgType: ServerMsg_Map,
+		Map:     mapUpdate,
+	}
+}
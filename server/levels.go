@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/labstack/echo"
+
+	log "github.com/donbattery/bnj/logger"
+	"github.com/donbattery/bnj/model"
+	"github.com/donbattery/bnj/utils"
+)
+
+// listLevels responds with the sorted names of the levels
+func (s *Server) listLevels(c echo.Context) error {
+	names, err := utils.EchoDB(c).RecordKeys("levels")
+	if err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to list the levels %s", err.Error()))
+	}
+	sort.Strings(names)
+	return c.JSON(http.StatusOK, names)
+}
+
+// getLevel responds with the level named in the path
+func (s *Server) getLevel(c echo.Context) error {
+	if !model.LevelName.MatchString(c.Param("name")) {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid level name %s", c.Param("name")))
+	}
+	db := utils.EchoDB(c)
+	keyChain := utils.Chain("levels", c.Param("name"))
+	if db.GetType(keyChain) != "Key" {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Level %s does not exist", c.Param("name")))
+	}
+	var level model.Level
+	if err := db.Get(keyChain, &level); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to get level %s %s", c.Param("name"), err.Error()))
+	}
+	return c.JSON(http.StatusOK, level)
+}
+
+// createLevel stores the valid level of the request body, if there is no level with the same name
+func (s *Server) createLevel(c echo.Context) error {
+	var level model.Level
+	if err := c.Bind(&level); err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid Level JSON %s", err.Error()))
+	}
+	if err := level.Validate(); err != nil {
+		return invalidLevel(c, err)
+	}
+
+	db := utils.EchoDB(c)
+	keyChain := utils.Chain("levels", level.Name)
+	if db.GetType(keyChain) != "Undefined" {
+		return c.String(http.StatusConflict, fmt.Sprintf("Level %s already exists", level.Name))
+	}
+	if err := db.Set(keyChain, level); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to create level %s %s", level.Name, err.Error()))
+	}
+	log.Infof("Level %s created", level.Name)
+	return c.JSON(http.StatusCreated, level)
+}
+
+// updateLevel replaces the level named in the path with the valid level of the request body
+func (s *Server) updateLevel(c echo.Context) error {
+	var level model.Level
+	if err := c.Bind(&level); err != nil {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid Level JSON %s", err.Error()))
+	}
+	if level.Name == "" {
+		level.Name = c.Param("name")
+	}
+	if level.Name != c.Param("name") {
+		return c.String(http.StatusBadRequest, "The name of a level cannot be changed")
+	}
+	if err := level.Validate(); err != nil {
+		return invalidLevel(c, err)
+	}
+
+	db := utils.EchoDB(c)
+	keyChain := utils.Chain("levels", level.Name)
+	if db.GetType(keyChain) != "Key" {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Level %s does not exist", level.Name))
+	}
+	if err := db.Set(keyChain, level); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to update level %s %s", level.Name, err.Error()))
+	}
+	log.Infof("Level %s updated", level.Name)
+	return c.JSON(http.StatusOK, level)
+}
+
+// deleteLevel removes the level named in the path
+func (s *Server) deleteLevel(c echo.Context) error {
+	if !model.LevelName.MatchString(c.Param("name")) {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid level name %s", c.Param("name")))
+	}
+	db := utils.EchoDB(c)
+	keyChain := utils.Chain("levels", c.Param("name"))
+	if db.GetType(keyChain) != "Key" {
+		return c.String(http.StatusNotFound, fmt.Sprintf("Level %s does not exist", c.Param("name")))
+	}
+	if err := db.Del(keyChain); err != nil {
+		return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to delete level %s %s", c.Param("name"), err.Error()))
+	}
+	log.Infof("Level %s deleted", c.Param("name"))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// invalidLevel responds with the field level validation errors of a level
+func invalidLevel(c echo.Context, err error) error {
+	if errs, ok := err.(validation.Errors); ok {
+		return c.JSON(http.StatusUnprocessableEntity, errs)
+	}
+	return c.String(http.StatusInternalServerError, fmt.Sprintf("Failed to validate the level %s", err.Error()))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c2fo/testify/require"
+
+	"github.com/donbattery/bnj/database"
+	"github.com/donbattery/bnj/model"
+)
+
+func Test_Levels(t *testing.T) {
+	req := require.New(t)
+
+	dir, err := ioutil.TempDir("", "bnj")
+	req.NoError(err)
+	defer os.RemoveAll(dir)
+	db := database.New()
+	req.NoError(db.Init(model.GetDBInitConfig(&model.DataBase{Type: "bolt", URL: filepath.Join(dir, "bnj.db")})))
+	defer db.Close()
+
+	newServer := func(password string) *Server {
+		conf := model.DefaultConf()
+		conf.Admin.Password = password
+		ctx := context.WithValue(context.WithValue(context.Background(), "config", conf), "database", db)
+		s := NewServer(ctx)
+		s.routes()
+		return s
+	}
+	s := newServer("secret-pass")
+	send := func(s *Server, method, path, body, password string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if password != "" {
+			r.SetBasicAuth("admin", password)
+		}
+		w := httptest.NewRecorder()
+		s.srv.ServeHTTP(w, r)
+		return w
+	}
+
+	level := `{"name": "arena", "background": "#000000", "rows": ["111111", "100001", "100001", "111111"], "tile_size": 16}`
+
+	t_cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		password string
+		status   int
+	}{
+		{name: "create without a password", method: http.MethodPost, path: "/levels", body: level, status: http.StatusUnauthorized},
+		{name: "create with a wrong password", method: http.MethodPost, path: "/levels", body: level, password: "wrong-pass", status: http.StatusUnauthorized},
+		{name: "create", method: http.MethodPost, path: "/levels", body: level, password: "secret-pass", status: http.StatusCreated},
+		{name: "create a duplicate", method: http.MethodPost, path: "/levels", body: level, password: "secret-pass", status: http.StatusConflict},
+		{name: "create an invalid level", method: http.MethodPost, path: "/levels", body: strings.Replace(level, `"111111", "100001"`, `"110111", "100001"`, 1), password: "secret-pass", status: http.StatusUnprocessableEntity},
+		{name: "create from invalid JSON", method: http.MethodPost, path: "/levels", body: "{", password: "secret-pass", status: http.StatusBadRequest},
+		{name: "get", method: http.MethodGet, path: "/levels/arena", password: "secret-pass", status: http.StatusOK},
+		{name: "get a missing level", method: http.MethodGet, path: "/levels/missing", password: "secret-pass", status: http.StatusNotFound},
+		{name: "get an invalid name", method: http.MethodGet, path: "/levels/are.na", password: "secret-pass", status: http.StatusBadRequest},
+		{name: "list", method: http.MethodGet, path: "/levels", password: "secret-pass", status: http.StatusOK},
+		{name: "delete an invalid name", method: http.MethodDelete, path: "/levels/are.na", password: "secret-pass", status: http.StatusBadRequest},
+		{name: "delete without a password", method: http.MethodDelete, path: "/levels/arena", status: http.StatusUnauthorized},
+		{name: "delete", method: http.MethodDelete, path: "/levels/arena", password: "secret-pass", status: http.StatusNoContent},
+		{name: "delete a missing level", method: http.MethodDelete, path: "/levels/arena", password: "secret-pass", status: http.StatusNotFound},
+	}
+
+	for _, t_case := range t_cases {
+		w := send(s, t_case.method, t_case.path, t_case.body, t_case.password)
+		req.Equal(t_case.status, w.Code, "The status of %s should be %d: %s", t_case.name, t_case.status, w.Body.String())
+
+		switch t_case.name {
+		case "get":
+			var got model.Level
+			req.NoError(json.Unmarshal(w.Body.Bytes(), &got))
+			req.Equal("arena", got.Name, "The stored level should be returned")
+		case "list":
+			var names []string
+			req.NoError(json.Unmarshal(w.Body.Bytes(), &names))
+			req.Equal([]string{"arena"}, names, "The names of the levels should be listed")
+		}
+	}
+
+	// Without a configured password the level endpoints are disabled, but the config is served
+	disabled := newServer("")
+	req.Equal(http.StatusUnauthorized, send(disabled, http.MethodGet, "/levels", "", "secret-pass").Code, "The levels should be disabled without a password")
+	req.Equal(http.StatusUnauthorized, send(disabled, http.MethodPost, "/levels", level, "").Code, "The levels should be disabled without a password")
+	req.Equal(http.StatusOK, send(disabled, http.MethodPost, "/admin", "", "").Code, "The config should be served without a credential")
+}
@@ -2,9 +2,11 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/donbattery/bnj/model"
 	"github.com/donbattery/bnj/utils"
@@ -37,6 +39,13 @@ func (s *Server) SetConnectFn(f func(clientId string, conn *websocket.Conn)) {
 
 // Start sets up and starts the HTTP server
 func (s *Server) Start() error {
+	s.routes()
+	// Run the server
+	return s.srv.Start(":" + strconv.Itoa(utils.Conf(s.ctx).Port))
+}
+
+// routes sets up the middlewares and the routes of the server
+func (s *Server) routes() {
 	// Inject the Configs and the Database into the server's context
 	s.srv.Use(newInjectorMiddleware(s.ctx))
 	// Use the Logger middleware
@@ -47,10 +56,13 @@ func (s *Server) Start() error {
 	s.srv.Static("/", "frontend")
 	// Upgrade the requests to /hub route into WebSocket connection
 	s.srv.GET("/hub", s.hub)
-	// Administrative endpoint
 	s.srv.POST("/admin", s.admin)
-	// Run the server
-	return s.srv.Start(":" + strconv.Itoa(utils.Conf(s.ctx).Port))
+	// The levels are managed by the admin, the endpoints are protected by the admin credential
+	s.srv.GET("/levels", s.listLevels, s.adminAuth())
+	s.srv.GET("/levels/:name", s.getLevel, s.adminAuth())
+	s.srv.POST("/levels", s.createLevel, s.adminAuth())
+	s.srv.PUT("/levels/:name", s.updateLevel, s.adminAuth())
+	s.srv.DELETE("/levels/:name", s.deleteLevel, s.adminAuth())
 }
 
 // newInjectorMiddlewar creates a new Echo middleware that injects the configs
@@ -67,12 +79,30 @@ func newInjectorMiddleware(ctx context.Context) func(echo.HandlerFunc) echo.Hand
 	}
 }
 
+// adminAuth creates a Basic Auth middleware, which lets through the requests with the admin credential
+// of the configs, if the admin has no password every request is rejected
+func (s *Server) adminAuth() echo.MiddlewareFunc {
+	return middleware.BasicAuth(func(user, password string, c echo.Context) (bool, error) {
+		admin := utils.EchoConf(c).Admin
+		if !admin.Enabled() {
+			return false, nil
+		}
+		validUser := subtle.ConstantTimeCompare([]byte(user), []byte(admin.User)) == 1
+		validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(admin.Password)) == 1
+		return validUser && validPassword, nil
+	})
+}
+
 func (s *Server) hub(c echo.Context) error {
 	// Get the Client's ID, return error if not found
 	clientId := c.QueryParam("client_id")
 	if clientId == "" {
 		return errors.New("Empty Client ID")
 	}
+	// The bots' Client IDs are reserved
+	if strings.HasPrefix(clientId, model.BotClientIdPrefix) {
+		return errors.New("Client ID is reserved for the bots")
+	}
 	// Upgrade the connection to WebSocket, return error if fails
 	ws, err := s.upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {